@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kvitebjorn/gol/internal/analysis"
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+// analyze runs the "gol analyze" subcommand, which steps an RLE pattern without
+// the GUI until it dies out or repeats, and reports what it turned into.
+func analyze(args []string) {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gol analyze [flags] [file.rle]\n\nReads the pattern from standard input if no file is given.")
+		fs.PrintDefaults()
+	}
+	ruleStr := fs.String("rule", "", "Rule in B/S notation, overriding the file's (defaults to B3/S23)")
+	maxGens := fs.Int("generations", 10000, "Number of generations to run before giving up")
+	fs.Parse(args)
+
+	var in io.Reader = os.Stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open RLE file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+	b, r, err := util.ImportRLE(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to import RLE: %v\n", err)
+		os.Exit(1)
+	}
+	if *ruleStr != "" {
+		if r, err = rule.Parse(*ruleStr); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse rule: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	g := game.Game{BoardA: b, BoardB: board.NewDefault(), UseA: true, Turn: 1, Rule: r}
+	res, err := analysis.Run(context.Background(), &g, *maxGens)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to analyze pattern: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Rule:         %s\n", g.ActiveRule())
+	fmt.Printf("Result:       %s\n", res.Kind)
+	if res.Kind != analysis.Unknown {
+		fmt.Printf("Start:        generation %d\n", res.Start)
+	}
+	if res.Kind == analysis.Oscillator || res.Kind == analysis.Spaceship {
+		fmt.Printf("Period:       %d\n", res.Period)
+	}
+	if res.Kind == analysis.Oscillator && res.Wrapped {
+		fmt.Printf("Note:         the grid wraps around, so this may be a spaceship lapping it\n")
+	}
+	if res.Kind == analysis.Spaceship {
+		fmt.Printf("Displacement: (%d, %d)\n", res.Dx, res.Dy)
+		fmt.Printf("Speed:        %s %s\n", res.Speed(), res.Direction())
+	}
+	fmt.Printf("Generations:  %d\n", res.Generations)
+	if res.Kind == analysis.Unknown {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/gpu"
+)
+
+// doctor runs the "gol doctor" subcommand, which reports how GPU detection went
+// on this machine and which backend gol would use.
+func doctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	backend := fs.String("backend", game.BackendName(), fmt.Sprintf("Compute backend to report on, one of: %s", strings.Join(game.BackendNames(), ", ")))
+	fs.Parse(args)
+
+	if _, err := game.LookupBackend(*backend); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to select backend: %v\n", err)
+		os.Exit(1)
+	}
+	r := gpu.Detect(gpu.SystemLoader())
+	writeReport(os.Stdout, r, *backend)
+	if *backend == "cuda" && !r.Available {
+		os.Exit(1)
+	}
+}
+
+func writeReport(w io.Writer, r gpu.Report, backend string) {
+	fmt.Fprintf(w, "Go:              %s %s/%s, %d CPUs\n", runtime.Version(), runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	fmt.Fprintf(w, "LD_LIBRARY_PATH: %s\n", os.Getenv("LD_LIBRARY_PATH"))
+
+	fmt.Fprintln(w, "\nDetection:")
+	for _, c := range r.Checks {
+		switch {
+		case c.Err != nil:
+			fmt.Fprintf(w, "  FAIL %s: %v\n", c.Name, c.Err)
+		case c.Detail != "":
+			fmt.Fprintf(w, "  ok   %s: %s\n", c.Name, c.Detail)
+		default:
+			fmt.Fprintf(w, "  ok   %s\n", c.Name)
+		}
+	}
+
+	fmt.Fprintln(w, "\nLibraries tried:")
+	for _, a := range r.Tried {
+		if a.Err != nil {
+			fmt.Fprintf(w, "  %s: %v\n", a.Library, a.Err)
+		} else {
+			fmt.Fprintf(w, "  %s: loaded\n", a.Library)
+		}
+	}
+
+	fmt.Fprintf(w, "\nDevices: %d\n", len(r.Devices))
+	for i, name := range r.Devices {
+		fmt.Fprintf(w, "  %d: %s\n", i, name)
+	}
+
+	fmt.Fprintf(w, "\nBackend: %s\n", chosenBackend(backend, r.Available))
+}
+
+// chosenBackend describes the backend gol would compute generations with.
+func chosenBackend(name string, available bool) string {
+	switch {
+	case name == "auto" && available:
+		return "auto: cuda for large dense patterns, cpu otherwise"
+	case name == "auto":
+		return "auto: cpu, no usable GPU"
+	case name == "cuda" && !available:
+		return "cuda: unavailable, gol refuses to start"
+	}
+	return name
+}
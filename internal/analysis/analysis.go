@@ -0,0 +1,226 @@
+// Package analysis tells where a pattern ends up: whether it dies out, settles
+// into a still life, oscillates, or travels as a spaceship, and with which period
+// and speed.
+package analysis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+)
+
+// Kind is what a pattern turns into.
+type Kind int
+
+const (
+	// Unknown means the pattern didn't repeat within the generations examined.
+	Unknown Kind = iota
+	Dead
+	StillLife
+	Oscillator
+	Spaceship
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Dead:
+		return "dead"
+	case StillLife:
+		return "still life"
+	case Oscillator:
+		return "oscillator"
+	case Spaceship:
+		return "spaceship"
+	}
+	return "unknown"
+}
+
+// Result is what a Detector found out about a pattern.
+type Result struct {
+	Kind Kind
+	// Start is the first generation of the cycle, the one the pattern died at for
+	// Dead, and Period the number of generations it takes to repeat.
+	Start, Period int
+	// Dx and Dy are the number of columns and rows the pattern moves by every period.
+	Dx, Dy int
+	// Generations is the number of generations examined.
+	Generations int
+	// Wrapped is set when the grid's edges are joined, as on a torus. Generations
+	// are then compared in place, since a pattern crossing an edge is split in two:
+	// a spaceship comes back to where it started after some laps of the grid, and
+	// reads as an oscillator with that period.
+	Wrapped bool
+}
+
+// Speed returns the speed of a spaceship as a fraction of c, the speed of light of
+// one cell per generation: c/4 for the glider, which moves one cell diagonally
+// every 4 generations, or 2c/5 for a ship moving 2 cells every 5.
+// It returns "0" for patterns that don't move.
+func (r Result) Speed() string {
+	d := max(abs(r.Dx), abs(r.Dy))
+	if d == 0 || r.Period == 0 {
+		return "0"
+	}
+	g := gcd(d, r.Period)
+	num, den := d/g, r.Period/g
+	c := "c"
+	if num > 1 {
+		c = fmt.Sprintf("%dc", num)
+	}
+	if den == 1 {
+		return c
+	}
+	return fmt.Sprintf("%s/%d", c, den)
+}
+
+// Direction returns how a spaceship moves: "orthogonal", "diagonal" or "oblique".
+func (r Result) Direction() string {
+	switch {
+	case r.Dx == 0 || r.Dy == 0:
+		return "orthogonal"
+	case abs(r.Dx) == abs(r.Dy):
+		return "diagonal"
+	}
+	return "oblique"
+}
+
+func (r Result) String() string {
+	switch r.Kind {
+	case Unknown:
+		return fmt.Sprintf("no repeat within %d generations", r.Generations)
+	case Dead:
+		return fmt.Sprintf("dead from generation %d", r.Start)
+	case StillLife:
+		return fmt.Sprintf("still life from generation %d", r.Start)
+	case Oscillator:
+		if r.Wrapped {
+			return fmt.Sprintf("oscillator or spaceship with period %d from generation %d, on a wrapped grid", r.Period, r.Start)
+		}
+		return fmt.Sprintf("oscillator with period %d from generation %d", r.Period, r.Start)
+	}
+	return fmt.Sprintf("%s %s spaceship with period %d, moving (%d, %d), from generation %d",
+		r.Speed(), r.Direction(), r.Period, r.Dx, r.Dy, r.Start)
+}
+
+// Hash returns a hash of the board's cells relative to their bounding box, so that
+// a pattern hashes the same wherever it is, and the corner of that box.
+// Cells are hashed one by one and summed, as boards iterate over their cells in
+// no particular order.
+func Hash(b board.Board) (h uint64, minRow, minCol int) {
+	minRow, minCol, _, _ = b.Bounds()
+	return hashFrom(b, minRow, minCol), minRow, minCol
+}
+
+// hashFrom hashes the board's cells relative to a corner.
+func hashFrom(b board.Board, minRow, minCol int) uint64 {
+	var h uint64
+	for pos, state := range b.All() {
+		key := uint64(uint32(pos[0]-minRow))<<32 | uint64(uint32(pos[1]-minCol))
+		h += mix(key ^ mix(uint64(state)))
+	}
+	return mix(h ^ uint64(b.Len()))
+}
+
+// mix is the finalizer of splitmix64, which spreads every bit of x over the result.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Detector is given the generations of a pattern one by one, and finds out when
+// the pattern repeats, up to a translation. Generations are told apart by their
+// 64-bit Hash, so two different ones colliding, while unlikely, reads as a repeat.
+type Detector struct {
+	seen map[uint64]position
+	// n is the number of generations added.
+	n int
+	// wrapped is set on grids with joined edges, where generations are compared
+	// in place rather than up to a translation (see Result.Wrapped).
+	wrapped bool
+}
+
+// position is where a pattern was seen: its turn and the corner of its bounding box.
+type position struct {
+	turn, row, col int
+}
+
+// NewDetector returns a Detector that has seen no generations.
+func NewDetector() *Detector {
+	return &Detector{seen: make(map[uint64]position)}
+}
+
+// NewDetectorFor returns a Detector for patterns on a grid of topology t.
+func NewDetectorFor(t board.Topology) *Detector {
+	d := NewDetector()
+	d.wrapped = t.Bounded() && t.Kind != board.Plane
+	return d
+}
+
+// Add adds the generation of a pattern at a turn, which must follow the one added
+// before. It reports whether the pattern has died out or repeated a generation
+// it was in before, and the Result if so.
+func (d *Detector) Add(turn int, b board.Board) (Result, bool) {
+	d.n++
+	if b.Len() == 0 {
+		return Result{Kind: Dead, Start: turn, Period: 1, Generations: d.n, Wrapped: d.wrapped}, true
+	}
+	var h uint64
+	var row, col int
+	if d.wrapped {
+		h = hashFrom(b, 0, 0)
+	} else {
+		h, row, col = Hash(b)
+	}
+	prev, ok := d.seen[h]
+	if !ok {
+		d.seen[h] = position{turn, row, col}
+		return Result{Generations: d.n, Wrapped: d.wrapped}, false
+	}
+	r := Result{Start: prev.turn, Period: turn - prev.turn, Dx: col - prev.col, Dy: row - prev.row, Generations: d.n, Wrapped: d.wrapped}
+	switch {
+	case r.Dx != 0 || r.Dy != 0:
+		r.Kind = Spaceship
+	case r.Period == 1:
+		r.Kind = StillLife
+	default:
+		r.Kind = Oscillator
+	}
+	return r, true
+}
+
+// Run steps a game until its pattern dies out or repeats, for at most maxGens
+// generations, and returns what it turned into. The game is left at the last
+// generation examined. Run checks ctx between generations and returns ctx.Err()
+// as soon as it is done.
+func Run(ctx context.Context, g *game.Game, maxGens int) (Result, error) {
+	d := NewDetectorFor(g.ActiveTopology())
+	r, done := d.Add(g.Turn, g.CurrentBoard())
+	for i := 0; i < maxGens && !done; i++ {
+		if err := ctx.Err(); err != nil {
+			return r, err
+		}
+		g.Tick()
+		r, done = d.Add(g.Turn, g.CurrentBoard())
+	}
+	return r, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
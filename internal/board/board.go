@@ -0,0 +1,86 @@
+package board
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Board stores the cells of a pattern on an unbounded grid.
+// Implementations register themselves with Register so they can be chosen at startup.
+type Board interface {
+	// At returns the state of a cell.
+	At(row, col int) Cell
+	// Set changes the state of a cell; setting it to Dead removes it.
+	Set(row, col int, val Cell)
+	// Bounds returns the bounding box of the non-dead cells, or all zeros if there are none.
+	Bounds() (minRow, minCol, maxRow, maxCol int)
+	// Len returns the number of non-dead cells.
+	Len() int
+	// All iterates over the non-dead cells and their states, in no particular order.
+	All() iter.Seq2[[2]int, Cell]
+	// AliveCellsWithinBounds returns the non-dead cells with minRow <= row < maxRow
+	// and minCol <= col < maxCol.
+	AliveCellsWithinBounds(minCol, minRow, maxCol, maxRow int) [][2]int
+	// Clone returns an independent copy of the board.
+	Clone() Board
+	// Clear removes every cell.
+	Clear()
+}
+
+// Factory creates an empty board.
+type Factory func() Board
+
+var (
+	factories   = map[string]Factory{}
+	defaultName = "map"
+)
+
+// Register makes a board implementation available under a name.
+// It panics if the name is already taken.
+func Register(name string, f Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("board: implementation %q registered twice", name))
+	}
+	factories[name] = f
+}
+
+// Names returns the names of the registered implementations, sorted.
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New returns an empty board of the named implementation.
+func New(name string) (Board, error) {
+	f, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown board %q: expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return f(), nil
+}
+
+// SetDefault chooses the implementation returned by NewDefault.
+func SetDefault(name string) error {
+	if _, err := New(name); err != nil {
+		return err
+	}
+	defaultName = name
+	return nil
+}
+
+// Default returns the name of the implementation returned by NewDefault.
+func Default() string {
+	return defaultName
+}
+
+// NewDefault returns an empty board of the implementation chosen with SetDefault,
+// the sparse map of InfiniteGrid unless another was chosen.
+func NewDefault() Board {
+	return factories[defaultName]()
+}
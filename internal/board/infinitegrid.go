@@ -1,19 +1,38 @@
 package board
 
-import "maps"
+import (
+	"iter"
+	"maps"
+)
 
-// Cell represents a single cell in the Game of Life grid.
-type Cell bool
+// Cell represents the state of a single cell in the Game of Life grid.
+// Two-state rules only use Dead and Alive; Generations rules use the
+// values above Alive for the dying (refractory) states.
+type Cell uint8
+
+const (
+	Dead  Cell = 0
+	Alive Cell = 1
+)
+
+// IsAlive reports whether the cell is alive, i.e. counts as a live neighbor.
+func (c Cell) IsAlive() bool {
+	return c == Alive
+}
+
+func init() {
+	Register("map", func() Board { return NewInfiniteGrid() })
+}
 
 // InfiniteGrid represents a sparse, infinite board using a map.
 type InfiniteGrid struct {
-	Cells                          map[[2]int]Cell // key: [row, col], value: alive/dead
+	Cells                          map[[2]int]Cell // key: [row, col], value: state of a non-dead cell
 	MinRow, MinCol, MaxRow, MaxCol int
 	BoundsValid                    bool
 }
 
-func NewInfiniteGrid() InfiniteGrid {
-	return InfiniteGrid{Cells: make(map[[2]int]Cell), BoundsValid: false}
+func NewInfiniteGrid() *InfiniteGrid {
+	return &InfiniteGrid{Cells: make(map[[2]int]Cell), BoundsValid: true}
 }
 
 func (g *InfiniteGrid) At(row, col int) Cell {
@@ -22,22 +41,25 @@ func (g *InfiniteGrid) At(row, col int) Cell {
 
 func (g *InfiniteGrid) Set(row, col int, val Cell) {
 	key := [2]int{row, col}
-	if val {
-		if _, exists := g.Cells[key]; !exists {
-			g.Cells[key] = true
-			if g.BoundsValid {
-				if row < g.MinRow {
-					g.MinRow = row
-				}
-				if row > g.MaxRow {
-					g.MaxRow = row
-				}
-				if col < g.MinCol {
-					g.MinCol = col
-				}
-				if col > g.MaxCol {
-					g.MaxCol = col
-				}
+	if val != Dead {
+		_, exists := g.Cells[key]
+		g.Cells[key] = val
+		if !exists && len(g.Cells) == 1 {
+			// The first cell of an empty grid is its bounds
+			g.MinRow, g.MaxRow, g.MinCol, g.MaxCol = row, row, col, col
+			g.BoundsValid = true
+		} else if !exists && g.BoundsValid {
+			if row < g.MinRow {
+				g.MinRow = row
+			}
+			if row > g.MaxRow {
+				g.MaxRow = row
+			}
+			if col < g.MinCol {
+				g.MinCol = col
+			}
+			if col > g.MaxCol {
+				g.MaxCol = col
 			}
 		}
 	} else {
@@ -87,7 +109,7 @@ func (g *InfiniteGrid) Bounds() (minRow, minCol, maxRow, maxCol int) {
 }
 
 // DeepCopy returns a deep copy of the InfiniteGrid.
-func (g InfiniteGrid) DeepCopy() InfiniteGrid {
+func (g *InfiniteGrid) DeepCopy() *InfiniteGrid {
 	copy := NewInfiniteGrid()
 	maps.Copy(copy.Cells, g.Cells)
 	copy.MinRow = g.MinRow
@@ -98,7 +120,28 @@ func (g InfiniteGrid) DeepCopy() InfiniteGrid {
 	return copy
 }
 
-// AliveCells returns a slice of coordinates of all currently alive cells.
+func (g *InfiniteGrid) Clone() Board {
+	return g.DeepCopy()
+}
+
+// Clear removes every cell, keeping the map's storage for the cells set afterwards.
+// The bounds stay valid, so a grid filled by Set after Clear never has to scan
+// its cells in Bounds.
+func (g *InfiniteGrid) Clear() {
+	clear(g.Cells)
+	g.MinRow, g.MinCol, g.MaxRow, g.MaxCol = 0, 0, 0, 0
+	g.BoundsValid = true
+}
+
+func (g *InfiniteGrid) Len() int {
+	return len(g.Cells)
+}
+
+func (g *InfiniteGrid) All() iter.Seq2[[2]int, Cell] {
+	return maps.All(g.Cells)
+}
+
+// AliveCells returns a slice of coordinates of all currently non-dead cells.
 // This provides a fast sparse iteration path for rendering and other ops.
 func (g *InfiniteGrid) AliveCells() [][2]int {
 	out := make([][2]int, 0, len(g.Cells))
@@ -0,0 +1,381 @@
+package board
+
+import (
+	"iter"
+	"maps"
+	"math/bits"
+)
+
+// TileSize is the width and height in cells of the tiles of a TiledGrid.
+const TileSize = 64
+
+func init() {
+	Register("tiled", func() Board { return NewTiledGrid() })
+}
+
+// tile is a TileSize x TileSize square of cells. Bit c of rows[r] is set when the
+// cell at row r, column c of the tile is alive.
+type tile struct {
+	rows [TileSize]uint64
+
+	// states holds the cells in the dying states of Generations rules, which don't
+	// fit in the bits, keyed by r*TileSize + c.
+	states map[int]Cell
+}
+
+func (t *tile) empty() bool {
+	if len(t.states) > 0 {
+		return false
+	}
+	for _, row := range t.rows {
+		if row != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TiledGrid represents a sparse, infinite board as a map of bit-packed tiles.
+// Dense patterns take a bit per cell instead of a map entry, and two-state rules
+// can be stepped 64 cells at a time with bitwise operations (see StepLife).
+type TiledGrid struct {
+	tiles  map[[2]int]*tile // key: [tileRow, tileCol], the cell coordinates divided by TileSize
+	count  int
+	states int // number of cells in dying states
+
+	minRow, minCol, maxRow, maxCol int
+	boundsValid                    bool
+
+	// free holds the tiles dropped by Clear, reused before allocating new ones, and
+	// pending is StepLife's scratch set of tiles to compute.
+	free    []*tile
+	pending map[[2]int]struct{}
+}
+
+func NewTiledGrid() *TiledGrid {
+	return &TiledGrid{tiles: make(map[[2]int]*tile)}
+}
+
+// tileIndex splits a cell position into its tile key and its row and column in the tile.
+func tileIndex(row, col int) (key [2]int, r, c int) {
+	tr, tc := floorDiv(row, TileSize), floorDiv(col, TileSize)
+	return [2]int{tr, tc}, row - tr*TileSize, col - tc*TileSize
+}
+
+func (g *TiledGrid) At(row, col int) Cell {
+	key, r, c := tileIndex(row, col)
+	t := g.tiles[key]
+	if t == nil {
+		return Dead
+	}
+	if t.rows[r]&(1<<c) != 0 {
+		return Alive
+	}
+	return t.states[r*TileSize+c]
+}
+
+func (g *TiledGrid) Set(row, col int, val Cell) {
+	key, r, c := tileIndex(row, col)
+	t := g.tiles[key]
+	if t == nil {
+		if val == Dead {
+			return
+		}
+		t = g.newTile()
+		g.tiles[key] = t
+	}
+
+	// Remove the old state
+	bit := uint64(1) << c
+	i := r*TileSize + c
+	if t.rows[r]&bit != 0 {
+		t.rows[r] &^= bit
+		g.count--
+	} else if _, ok := t.states[i]; ok {
+		delete(t.states, i)
+		g.count--
+		g.states--
+	}
+
+	switch val {
+	case Dead:
+		if t.empty() {
+			delete(g.tiles, key)
+			g.free = append(g.free, t)
+		}
+	case Alive:
+		t.rows[r] |= bit
+		g.count++
+	default:
+		if t.states == nil {
+			t.states = make(map[int]Cell)
+		}
+		t.states[i] = val
+		g.count++
+		g.states++
+	}
+	g.boundsValid = false
+}
+
+func (g *TiledGrid) Bounds() (minRow, minCol, maxRow, maxCol int) {
+	if g.boundsValid {
+		return g.minRow, g.minCol, g.maxRow, g.maxCol
+	}
+	first := true
+	for pos := range g.All() {
+		r, c := pos[0], pos[1]
+		if first {
+			minRow, maxRow = r, r
+			minCol, maxCol = c, c
+			first = false
+			continue
+		}
+		minRow, maxRow = min(minRow, r), max(maxRow, r)
+		minCol, maxCol = min(minCol, c), max(maxCol, c)
+	}
+	// An empty board has all-zero bounds
+	g.minRow, g.minCol, g.maxRow, g.maxCol = minRow, minCol, maxRow, maxCol
+	g.boundsValid = true
+	return minRow, minCol, maxRow, maxCol
+}
+
+func (g *TiledGrid) Len() int {
+	return g.count
+}
+
+// TwoState reports whether every non-dead cell is Alive, i.e. the board holds no
+// dying cells of a Generations rule.
+func (g *TiledGrid) TwoState() bool {
+	return g.states == 0
+}
+
+func (g *TiledGrid) All() iter.Seq2[[2]int, Cell] {
+	return func(yield func([2]int, Cell) bool) {
+		for key, t := range g.tiles {
+			row0, col0 := key[0]*TileSize, key[1]*TileSize
+			for r, word := range t.rows {
+				for word != 0 {
+					c := bits.TrailingZeros64(word)
+					word &= word - 1
+					if !yield([2]int{row0 + r, col0 + c}, Alive) {
+						return
+					}
+				}
+			}
+			for i, state := range t.states {
+				if !yield([2]int{row0 + i/TileSize, col0 + i%TileSize}, state) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (g *TiledGrid) AliveCellsWithinBounds(minCol, minRow, maxCol, maxRow int) [][2]int {
+	out := make([][2]int, 0)
+	for key, t := range g.tiles {
+		row0, col0 := key[0]*TileSize, key[1]*TileSize
+		if row0 >= maxRow || row0+TileSize <= minRow || col0 >= maxCol || col0+TileSize <= minCol {
+			continue
+		}
+		for r, word := range t.rows {
+			if row := row0 + r; row < minRow || row >= maxRow {
+				continue
+			}
+			for word != 0 {
+				c := bits.TrailingZeros64(word)
+				word &= word - 1
+				if col := col0 + c; col >= minCol && col < maxCol {
+					out = append(out, [2]int{row0 + r, col})
+				}
+			}
+		}
+		for i := range t.states {
+			row, col := row0+i/TileSize, col0+i%TileSize
+			if row >= minRow && row < maxRow && col >= minCol && col < maxCol {
+				out = append(out, [2]int{row, col})
+			}
+		}
+	}
+	return out
+}
+
+func (g *TiledGrid) Clone() Board {
+	clone := &TiledGrid{
+		tiles:  make(map[[2]int]*tile, len(g.tiles)),
+		count:  g.count,
+		states: g.states,
+	}
+	for key, t := range g.tiles {
+		ct := &tile{rows: t.rows}
+		if len(t.states) > 0 {
+			ct.states = maps.Clone(t.states)
+		}
+		clone.tiles[key] = ct
+	}
+	return clone
+}
+
+// newTile returns an empty tile, reusing one dropped by Clear if there is one.
+func (g *TiledGrid) newTile() *tile {
+	if n := len(g.free); n > 0 {
+		t := g.free[n-1]
+		g.free = g.free[:n-1]
+		return t
+	}
+	return &tile{}
+}
+
+// Clear removes every cell, keeping the tiles and the map's storage for the cells
+// set afterwards.
+func (g *TiledGrid) Clear() {
+	for _, t := range g.tiles {
+		t.rows = [TileSize]uint64{}
+		clear(t.states)
+		g.free = append(g.free, t)
+	}
+	clear(g.tiles)
+	g.count = 0
+	g.states = 0
+	g.boundsValid = false
+}
+
+// StepLife writes the next generation of a two-state totalistic rule to dst.
+// Birth and survive are masks indexed by neighbor count, as in rule.Rule, and
+// neighbors selects the neighbors that count, with bit i standing for the i-th
+// Moore neighbor in row-major order (0xff for all of them).
+// Every tile row is stepped at once: the neighbors of its 64 cells are shifted
+// into place as whole words and summed with bitwise adders.
+// The board must be TwoState.
+func (g *TiledGrid) StepLife(dst *TiledGrid, birth, survive uint16, neighbors uint8) {
+	dst.Clear()
+
+	// Tiles that may hold live cells next generation
+	if g.pending == nil {
+		g.pending = make(map[[2]int]struct{})
+	}
+	keys := g.pending
+	clear(keys)
+	for key := range g.tiles {
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				keys[[2]int{key[0] + dr, key[1] + dc}] = struct{}{}
+			}
+		}
+	}
+
+	// rows returns the rows of a tile, or nil if it is empty
+	rows := func(tr, tc int) *[TileSize]uint64 {
+		if t := g.tiles[[2]int{tr, tc}]; t != nil {
+			return &t.rows
+		}
+		return nil
+	}
+	word := func(t *[TileSize]uint64, r int) uint64 {
+		if t == nil {
+			return 0
+		}
+		return t[r]
+	}
+
+	// Neighbor words in the order of rule.Neighbors; unselected neighbors stay zero
+	var n [8]uint64
+	for key := range keys {
+		tr, tc := key[0], key[1]
+		var around [3][3]*[TileSize]uint64
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				around[dr+1][dc+1] = rows(tr+dr, tc+dc)
+			}
+		}
+
+		// center, west and east words of row r, which may be -1 or TileSize
+		line := func(r int) (w, center, e uint64) {
+			i := 1
+			switch {
+			case r < 0:
+				i, r = 0, TileSize-1
+			case r >= TileSize:
+				i, r = 2, 0
+			}
+			return word(around[i][0], r), word(around[i][1], r), word(around[i][2], r)
+		}
+
+		var out [TileSize]uint64
+		alive := false
+		for r := 0; r < TileSize; r++ {
+			uw, u, ue := line(r - 1)
+			mw, m, me := line(r)
+			dw, d, de := line(r + 1)
+			// Bit c of a shifted word holds the cell at column c-1 (<<) or c+1 (>>)
+			n[0] = u<<1 | uw>>63
+			n[1] = u
+			n[2] = u>>1 | ue<<63
+			n[3] = m<<1 | mw>>63
+			n[4] = m>>1 | me<<63
+			n[5] = d<<1 | dw>>63
+			n[6] = d
+			n[7] = d>>1 | de<<63
+			for i := range n {
+				if neighbors&(1<<i) == 0 {
+					n[i] = 0
+				}
+			}
+			next := applyCounts(n, m, birth, survive)
+			out[r] = next
+			if next != 0 {
+				alive = true
+			}
+		}
+		if alive {
+			t := dst.newTile()
+			t.rows = out
+			dst.tiles[key] = t
+			for _, row := range out {
+				dst.count += bits.OnesCount64(row)
+			}
+		}
+	}
+}
+
+// applyCounts sums eight neighbor words bit by bit and returns the cells of the
+// center word that are alive next generation.
+func applyCounts(n [8]uint64, center uint64, birth, survive uint16) uint64 {
+	// Full adders reduce the eight inputs to the four bits of each cell's count
+	s0, c0 := fullAdd(n[0], n[1], n[2])
+	s1, c1 := fullAdd(n[3], n[4], n[5])
+	s2, c2 := n[6]^n[7], n[6]&n[7]
+	ones, c3 := fullAdd(s0, s1, s2)
+	t0, f0 := fullAdd(c0, c1, c2)
+	twos, f1 := t0^c3, t0&c3
+	fours, eights := f0^f1, f0&f1
+
+	var next uint64
+	for count := 0; count <= 8; count++ {
+		born, survives := birth&(1<<count) != 0, survive&(1<<count) != 0
+		if !born && !survives {
+			continue
+		}
+		eq := ^uint64(0)
+		for bit, plane := range [4]uint64{ones, twos, fours, eights} {
+			if count&(1<<bit) != 0 {
+				eq &= plane
+			} else {
+				eq &^= plane
+			}
+		}
+		switch {
+		case born && survives:
+			next |= eq
+		case born:
+			next |= eq &^ center
+		default:
+			next |= eq & center
+		}
+	}
+	return next
+}
+
+func fullAdd(a, b, c uint64) (sum, carry uint64) {
+	return a ^ b ^ c, a&b | c&(a^b)
+}
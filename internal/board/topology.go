@@ -0,0 +1,205 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TopologyKind is the way the edges of a bounded grid are joined.
+type TopologyKind int
+
+const (
+	// Unbounded is the default infinite grid.
+	Unbounded TopologyKind = iota
+	// Plane is a finite grid whose outside cells are always dead.
+	Plane
+	// Torus joins the top edge to the bottom and the left edge to the right.
+	Torus
+	// KleinBottle is a torus where one pair of joined edges is reversed.
+	KleinBottle
+	// CrossSurface is a torus where both pairs of joined edges are reversed.
+	CrossSurface
+)
+
+var topologyLetters = map[TopologyKind]byte{
+	Plane:        'P',
+	Torus:        'T',
+	KleinBottle:  'K',
+	CrossSurface: 'C',
+}
+
+// Topology describes a bounded grid in the style of Golly, e.g. "T100,80" for a
+// 100x80 torus. A bounded grid of width W spans the columns -W/2 .. W-1-W/2, and
+// likewise for rows, so patterns at the origin land near its center.
+type Topology struct {
+	Kind          TopologyKind
+	Width, Height int
+
+	// ShiftCol shifts columns by that much when a torus is crossed through its top
+	// and bottom edges, and ShiftRow shifts rows when it is crossed through its left
+	// and right edges. At most one of them is set.
+	ShiftCol, ShiftRow int
+
+	// TwistRows selects which pair of edges of a Klein bottle is reversed. When false,
+	// the top and bottom edges are reversed, mirroring columns ("K100*,80"); when true,
+	// the left and right edges are, mirroring rows ("K100,80*").
+	TwistRows bool
+}
+
+// Bounded reports whether the topology has edges.
+func (t Topology) Bounded() bool {
+	return t.Kind != Unbounded
+}
+
+// Extent returns the first and last row and column of a bounded grid.
+func (t Topology) Extent() (minRow, minCol, maxRow, maxCol int) {
+	minRow, minCol = -(t.Height / 2), -(t.Width / 2)
+	return minRow, minCol, minRow + t.Height - 1, minCol + t.Width - 1
+}
+
+// Contains reports whether a cell lies on the grid. Every cell lies on an unbounded grid.
+func (t Topology) Contains(row, col int) bool {
+	if !t.Bounded() {
+		return true
+	}
+	minRow, minCol, maxRow, maxCol := t.Extent()
+	return row >= minRow && row <= maxRow && col >= minCol && col <= maxCol
+}
+
+// Wrap maps a position, which may lie past the edges of the grid, to the cell it
+// refers to. It reports false for positions off a plane, whose cells are always dead.
+func (t Topology) Wrap(row, col int) (int, int, bool) {
+	if !t.Bounded() {
+		return row, col, true
+	}
+	minRow, minCol, _, _ := t.Extent()
+	r, c := row-minRow, col-minCol
+	if t.Kind == Plane {
+		return row, col, r >= 0 && r < t.Height && c >= 0 && c < t.Width
+	}
+
+	// Number of times the position crosses the top/bottom and left/right edges
+	crossRows, crossCols := floorDiv(r, t.Height), floorDiv(c, t.Width)
+	if t.Kind == Torus {
+		c += crossRows * t.ShiftCol
+		r += crossCols * t.ShiftRow
+		crossRows, crossCols = floorDiv(r, t.Height), floorDiv(c, t.Width)
+	}
+	r -= crossRows * t.Height
+	c -= crossCols * t.Width
+
+	mirrorCols := t.Kind == CrossSurface || (t.Kind == KleinBottle && !t.TwistRows)
+	mirrorRows := t.Kind == CrossSurface || (t.Kind == KleinBottle && t.TwistRows)
+	if mirrorCols && crossRows&1 != 0 {
+		c = t.Width - 1 - c
+	}
+	if mirrorRows && crossCols&1 != 0 {
+		r = t.Height - 1 - r
+	}
+	return r + minRow, c + minCol, true
+}
+
+// ParseTopology parses a bounded grid specification in Golly's notation: a kind
+// letter (P, T, K or C) followed by the width and height, as in "T100,80".
+// A single size gives a square grid. A torus may shift one pair of edges, as in
+// "T100+5,80", and a Klein bottle marks its reversed pair with '*', as in "K100*,80".
+func ParseTopology(s string) (Topology, error) {
+	var t Topology
+	if s == "" {
+		return t, fmt.Errorf("missing topology")
+	}
+	switch strings.ToUpper(s[:1]) {
+	case "P":
+		t.Kind = Plane
+	case "T":
+		t.Kind = Torus
+	case "K":
+		t.Kind = KleinBottle
+	case "C":
+		t.Kind = CrossSurface
+	default:
+		return t, fmt.Errorf("unknown topology %q: expected P, T, K or C", s[:1])
+	}
+
+	widthPart, heightPart, square := strings.Cut(s[1:], ",")
+	var widthTwist, heightTwist bool
+	var err error
+	if t.Width, widthTwist, t.ShiftCol, err = parseEdge(widthPart); err != nil {
+		return t, err
+	}
+	if !square {
+		t.Height = t.Width
+	} else if t.Height, heightTwist, t.ShiftRow, err = parseEdge(heightPart); err != nil {
+		return t, err
+	}
+
+	if (t.ShiftCol != 0 || t.ShiftRow != 0) && t.Kind != Torus {
+		return t, fmt.Errorf("only a torus may shift its edges")
+	}
+	if t.ShiftCol != 0 && t.ShiftRow != 0 {
+		return t, fmt.Errorf("only one pair of edges may be shifted")
+	}
+	if t.Kind == KleinBottle {
+		if widthTwist == heightTwist {
+			return t, fmt.Errorf("a Klein bottle needs exactly one size marked with '*'")
+		}
+		t.TwistRows = heightTwist
+	} else if widthTwist || heightTwist {
+		return t, fmt.Errorf("only a Klein bottle may reverse its edges with '*'")
+	}
+	return t, nil
+}
+
+// parseEdge parses one size of a topology, e.g. "100", "100*" or "100+5".
+func parseEdge(s string) (size int, twist bool, shift int, err error) {
+	sizePart := s
+	if i := strings.IndexAny(s, "*+-"); i >= 0 {
+		sizePart = s[:i]
+		s = s[i:]
+		if s[0] == '*' {
+			twist = true
+			s = s[1:]
+		}
+		if s != "" {
+			if shift, err = strconv.Atoi(s); err != nil || (s[0] != '+' && s[0] != '-') {
+				return 0, false, 0, fmt.Errorf("invalid shift %q", s)
+			}
+		}
+	}
+	size, err = strconv.Atoi(sizePart)
+	if err != nil || size < 1 || sizePart[0] == '+' {
+		return 0, false, 0, fmt.Errorf("grid size must be a positive number, got %q", sizePart)
+	}
+	return size, twist, shift, nil
+}
+
+// String returns the topology in the notation accepted by ParseTopology, or "" for
+// an unbounded grid.
+func (t Topology) String() string {
+	if !t.Bounded() {
+		return ""
+	}
+	edge := func(size int, twist bool, shift int) string {
+		s := strconv.Itoa(size)
+		if twist {
+			s += "*"
+		}
+		if shift != 0 {
+			s += fmt.Sprintf("%+d", shift)
+		}
+		return s
+	}
+	twistCols := t.Kind == KleinBottle && !t.TwistRows
+	twistRows := t.Kind == KleinBottle && t.TwistRows
+	return string(topologyLetters[t.Kind]) +
+		edge(t.Width, twistCols, t.ShiftCol) + "," + edge(t.Height, twistRows, t.ShiftRow)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
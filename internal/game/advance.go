@@ -0,0 +1,108 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+)
+
+// advanceJumpMin is the number of remaining generations from which Advance hands
+// the work to HashLife; below it, loading the pattern into the quadtree costs more
+// than stepping it.
+const advanceJumpMin = 64
+
+// advanceGpuBatch is the number of generations Advance runs on a device between
+// checks of its context.
+const advanceGpuBatch = 64
+
+// Progress is called by Advance after each batch of generations, with the number
+// of generations done so far and the number asked for.
+type Progress func(done, total int)
+
+// Advance steps the game by n generations in one call, letting the backend batch
+// the work: rules HashLife supports jump by the powers of two that make up n,
+// device backends keep the pattern on the device until the last generation, and
+// other rules step with Tick. After each batch, progress (which may be nil) is called.
+// Advance checks ctx between batches and returns ctx.Err() as soon as it is done,
+// leaving the game at the last completed generation.
+func (g *Game) Advance(ctx context.Context, n int, progress Progress) error {
+	if n < 0 {
+		return fmt.Errorf("can't advance by %d generations", n)
+	}
+	dev, useDevice := g.backend().(*deviceBackend)
+
+	// Generations run on the device but not downloaded yet
+	pending := 0
+	sync := func() {
+		if pending == 0 {
+			return
+		}
+		src, dst := g.buffers()
+		if err := g.storeGpu(dst, pending); err == nil {
+			g.UseA = !g.UseA
+			g.Turn += pending
+			g.recordHistory(g.Turn-pending, src)
+		} else {
+			// What the device computed is lost, so compute it again
+			g.closeGpu()
+			g.fallBack(dev, pending, err)
+			g.tickCpu(pending)
+		}
+		pending = 0
+	}
+	defer sync()
+
+	done := 0
+	for done < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		left := n - done
+		step := 1
+		switch {
+		case left >= advanceJumpMin && g.CanFastForward():
+			sync()
+			k := min(bits.Len(uint(left))-1, MaxFastForward)
+			if err := g.FastForward(k); err != nil {
+				return err
+			}
+			step = 1 << k
+		case useDevice:
+			step = min(left, advanceGpuBatch)
+			src, _ := g.buffers()
+			s, err := g.gpuSession(dev, src)
+			if err == nil {
+				err = s.Step(step)
+			}
+			if err == nil {
+				pending += step
+				break
+			}
+			// Carry on without the device from the last generation downloaded
+			useDevice = false
+			g.closeGpu()
+			g.fallBack(dev, pending+step, err)
+			g.tickCpu(pending + step)
+			pending = 0
+		default:
+			sync()
+			g.Tick()
+		}
+		done += step
+		if progress != nil {
+			progress(done, n)
+		}
+	}
+	return nil
+}
+
+// tickCpu advances the game by n generations with TickCpu.
+func (g *Game) tickCpu(n int) {
+	for range n {
+		src, dst := g.buffers()
+		g.TickCpu(src, dst)
+		g.UseA = !g.UseA
+		g.Turn++
+		g.recordHistory(g.Turn-1, src)
+	}
+}
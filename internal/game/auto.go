@@ -0,0 +1,103 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// The auto backend runs a pattern on the GPU when it is large and dense enough
+// to keep the device busy, and on the CPU otherwise: the CPU only visits live
+// cells and their neighbors, while the GPU pays for the whole bounding box.
+// A pattern has to clear the higher thresholds to move to the GPU, and fall
+// below the lower ones to move back, so one hovering around a threshold doesn't
+// switch back and forth.
+const (
+	// autoEvery is the number of generations between two decisions.
+	autoEvery = 16
+
+	autoEnterPopulation = 4096
+	autoLeavePopulation = 2048
+	// Densities are the fraction of the bounding box that is populated.
+	autoEnterDensity = 1.0 / 32
+	autoLeaveDensity = 1.0 / 128
+)
+
+// Decision is the auto backend's latest choice for a game, and the statistics
+// it was made from.
+type Decision struct {
+	// Backend is the name of the backend chosen, "cpu" or "cuda".
+	Backend string
+	// Turn is the generation the decision was made at.
+	Turn int
+	// Population is the number of non-dead cells, and Area the number of cells of
+	// their bounding box, or of the grid when it is bounded.
+	Population, Area int
+}
+
+// autoState holds the auto backend's decision for a game.
+type autoState struct {
+	decision Decision
+	// next is the turn of the next decision.
+	next int
+}
+
+// BackendDecision returns the latest choice of the auto backend for the game.
+// The zero Decision means the game hasn't run on the auto backend yet.
+func (g *Game) BackendDecision() Decision {
+	return g.auto.decision
+}
+
+// autoBackend picks the backend of every generation of a game, from its
+// population and bounding box (see Decision).
+type autoBackend struct{}
+
+func (a autoBackend) pick(g *Game) Backend {
+	s := &g.auto
+	if s.decision.Backend == "" || g.Turn >= s.next || g.Turn < s.decision.Turn {
+		a.decide(g)
+	}
+	return backends[s.decision.Backend]
+}
+
+// decide chooses the backend of the next autoEvery generations, logging switches.
+func (autoBackend) decide(g *Game) {
+	src := g.CurrentBoard()
+	d := Decision{Backend: "cpu", Turn: g.Turn, Population: src.Len(), Area: boundingArea(g, src)}
+	prev := g.auto.decision.Backend
+
+	if HasGpu() && backends["cuda"].Supports(g) {
+		density := float64(d.Population) / float64(d.Area)
+		enter := d.Population >= autoEnterPopulation && density >= autoEnterDensity
+		stay := prev == "cuda" && d.Population >= autoLeavePopulation && density >= autoLeaveDensity
+		if enter || stay {
+			d.Backend = "cuda"
+		}
+	}
+
+	if prev != "" && prev != d.Backend {
+		Logf("generation %d: switching from the %s to the %s backend (population %d, bounding box %d cells)",
+			d.Turn, prev, d.Backend, d.Population, d.Area)
+		if prev == "cuda" {
+			// Free the device for as long as the pattern stays on the CPU
+			g.closeGpu()
+		}
+	}
+	g.auto = autoState{decision: d, next: g.Turn + autoEvery}
+}
+
+// boundingArea returns the number of cells the GPU would step for a board: its
+// bounding box, or the whole grid when the game's topology is bounded.
+func boundingArea(g *Game, src board.Board) int {
+	minRow, minCol, maxRow, maxCol := src.Bounds()
+	if t := g.ActiveTopology(); t.Bounded() {
+		minRow, minCol, maxRow, maxCol = t.Extent()
+	}
+	return (maxRow - minRow + 1) * (maxCol - minCol + 1)
+}
+
+func (autoBackend) Supports(*Game) bool {
+	return true
+}
+
+func (a autoBackend) Tick(g *Game, src, dst board.Board) {
+	a.pick(g).Tick(g, src, dst)
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/gpu"
+)
+
+// Backend computes generations for a Game.
+// Implementations register themselves with RegisterBackend so they can be chosen
+// at startup with SetBackend.
+type Backend interface {
+	// Supports reports whether the backend can compute the game's next generation.
+	// Tick computes the generations of unsupported games on the CPU backend.
+	Supports(g *Game) bool
+	// Tick writes the generation after src to dst.
+	Tick(g *Game, src, dst board.Board)
+}
+
+var (
+	backends    = map[string]Backend{}
+	backendName = "auto"
+)
+
+func init() {
+	RegisterBackend("auto", autoBackend{})
+	RegisterBackend("cpu", cpuBackend{})
+	RegisterBackend("cuda", &deviceBackend{name: "cuda", newDevice: func() gpu.Device { return NewDevice() }})
+	RegisterBackend("dense", &deviceBackend{name: "dense", newDevice: gpu.NewCPUDevice})
+}
+
+// RegisterBackend makes a backend available under a name.
+// It panics if the name is already taken.
+func RegisterBackend(name string, b Backend) {
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("game: backend %q registered twice", name))
+	}
+	backends[name] = b
+}
+
+// BackendNames returns the names of the registered backends, sorted.
+func BackendNames() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LookupBackend returns the named backend.
+func LookupBackend(name string) (Backend, error) {
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q: expected one of %s", name, strings.Join(BackendNames(), ", "))
+	}
+	return b, nil
+}
+
+// SetBackend chooses the backend Tick computes generations with.
+func SetBackend(name string) error {
+	if _, err := LookupBackend(name); err != nil {
+		return err
+	}
+	backendName = name
+	return nil
+}
+
+// BackendName returns the name of the backend chosen with SetBackend, "auto"
+// unless another was chosen.
+func BackendName() string {
+	return backendName
+}
+
+// backend returns the backend that computes the game's next generation.
+func (g *Game) backend() Backend {
+	b := backends[backendName]
+	if a, ok := b.(autoBackend); ok {
+		b = a.pick(g)
+	}
+	if !b.Supports(g) {
+		b = backends["cpu"]
+	}
+	return b
+}
+
+// cpuBackend computes generations on the CPU, over Workers goroutines or with
+// change tracking when those are enabled.
+type cpuBackend struct{}
+
+func (cpuBackend) Supports(*Game) bool {
+	return true
+}
+
+func (cpuBackend) Tick(g *Game, src, dst board.Board) {
+	switch {
+	case TrackChanges && g.genericTick(src, dst):
+		g.TickTracked(src, dst)
+	case Workers > 1 && g.genericTick(src, dst):
+		g.TickParallel(src, dst, Workers)
+	default:
+		g.TickCpu(src, dst)
+	}
+}
+
+// deviceBackend computes generations with the tick kernel on the devices it
+// creates: the GPU for "cuda", and the kernel's CPU implementation for "dense",
+// which runs the same host-side code as the GPU without the hardware.
+type deviceBackend struct {
+	name      string
+	newDevice func() gpu.Device
+}
+
+func (b *deviceBackend) Supports(g *Game) bool {
+	return gpu.Supports(g.ActiveRule())
+}
+
+func (b *deviceBackend) Tick(g *Game, src, dst board.Board) {
+	g.tickDevice(b, src, dst)
+}
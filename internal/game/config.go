@@ -1,3 +1,29 @@
 package game
 
-var UseGpu bool
+import (
+	"log"
+	"sync"
+
+	"github.com/kvitebjorn/gol/internal/gpu"
+)
+
+// HasGpu reports whether the "auto" backend may use the GPU. It is only checked
+// once, as detection loads the CUDA libraries. Tests swap it, along with
+// NewDevice, to run the GPU path on a stand-in.
+var HasGpu = sync.OnceValue(gpu.HasCUDA)
+
+// NewDevice creates the device the "cuda" backend runs on. Tests swap in a
+// stand-in for the GPU.
+var NewDevice = gpu.NewCUDADevice
+
+// Workers is the number of goroutines Tick spreads a CPU generation over.
+// With 1, the default, every generation runs on TickCpu alone.
+var Workers = 1
+
+// TrackChanges makes Tick re-evaluate only the parts of the board that changed in
+// the previous generation (see TickTracked).
+var TrackChanges bool
+
+// Logf reports generations a backend failed to compute, which were computed on
+// the CPU instead. Tests swap it out to see the fallbacks.
+var Logf = log.Printf
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// editLogLimit is the number of edits an EditLog can undo.
+const editLogLimit = 1000
+
+// Edit is a change made to a game by hand, as opposed to a generation: a cell
+// toggled, a stroke drawn, a pattern imported. An EditLog applies it, and can
+// revert and apply it again.
+type Edit interface {
+	// Apply makes the change, remembering what it replaces.
+	Apply(g *Game)
+	// Revert undoes the last Apply.
+	Revert(g *Game)
+}
+
+// CellEdit sets cells of the current generation, through SetCell.
+type CellEdit struct {
+	cells []editCell
+}
+
+type editCell struct {
+	pos           [2]int
+	before, after board.Cell
+}
+
+// Set adds a cell to set to val. A cell set twice ends up with the last value.
+func (e *CellEdit) Set(row, col int, val board.Cell) {
+	e.cells = append(e.cells, editCell{pos: [2]int{row, col}, after: val})
+}
+
+// Len returns the number of cells set.
+func (e *CellEdit) Len() int {
+	return len(e.cells)
+}
+
+func (e *CellEdit) Apply(g *Game) {
+	for i := range e.cells {
+		c := &e.cells[i]
+		c.before = g.CurrentBoard().At(c.pos[0], c.pos[1])
+		g.SetCell(c.pos[0], c.pos[1], c.after)
+	}
+}
+
+func (e *CellEdit) Revert(g *Game) {
+	for i := len(e.cells) - 1; i >= 0; i-- {
+		c := &e.cells[i]
+		g.SetCell(c.pos[0], c.pos[1], c.before)
+	}
+}
+
+// EditLog records the edits made to a game so they can be undone and redone.
+// Generations aren't edits: an edit can only be undone at the generation it
+// left the game at, and redone at the one it was made at, so going back to an
+// edit means seeking there first (see Game.Seek).
+// The zero EditLog is empty and ready to use.
+type EditLog struct {
+	done, undone []loggedEdit
+}
+
+// loggedEdit is an edit and the turns of the game before and after it.
+type loggedEdit struct {
+	edit          Edit
+	before, after int
+}
+
+// Do applies an edit to the game and records it, forgetting the edits undone
+// before it.
+func (l *EditLog) Do(g *Game, e Edit) {
+	before := g.Turn
+	e.Apply(g)
+	l.done = append(l.done, loggedEdit{edit: e, before: before, after: g.Turn})
+	if len(l.done) > editLogLimit {
+		l.done[0] = loggedEdit{}
+		l.done = l.done[1:]
+	}
+	clear(l.undone)
+	l.undone = l.undone[:0]
+}
+
+// CanUndo reports whether Undo would revert an edit.
+func (l *EditLog) CanUndo(g *Game) bool {
+	return len(l.done) > 0 && l.done[len(l.done)-1].after == g.Turn
+}
+
+// CanRedo reports whether Redo would apply an edit.
+func (l *EditLog) CanRedo(g *Game) bool {
+	return len(l.undone) > 0 && l.undone[len(l.undone)-1].before == g.Turn
+}
+
+// Undo reverts the last edit, if the game is at the generation it left it at.
+// It reports whether there was one to revert.
+func (l *EditLog) Undo(g *Game) bool {
+	if !l.CanUndo(g) {
+		return false
+	}
+	e := l.done[len(l.done)-1]
+	l.done = l.done[:len(l.done)-1]
+	e.edit.Revert(g)
+	l.undone = append(l.undone, e)
+	return true
+}
+
+// Redo applies the last edit Undo reverted again, if the game is at the
+// generation it was made at. It reports whether there was one to apply.
+func (l *EditLog) Redo(g *Game) bool {
+	if !l.CanRedo(g) {
+		return false
+	}
+	e := l.undone[len(l.undone)-1]
+	l.undone = l.undone[:len(l.undone)-1]
+	e.edit.Apply(g)
+	l.done = append(l.done, e)
+	return true
+}
+
+// Clear forgets every edit.
+func (l *EditLog) Clear() {
+	*l = EditLog{}
+}
@@ -2,7 +2,8 @@ package game
 
 import (
 	"github.com/kvitebjorn/gol/internal/board"
-	"github.com/kvitebjorn/gol/internal/gpu"
+	"github.com/kvitebjorn/gol/internal/hashlife"
+	"github.com/kvitebjorn/gol/internal/rule"
 )
 
 // Game holds the state of the Game of Life, including double-buffered boards.
@@ -10,85 +11,171 @@ type Game struct {
 	// BoardA and BoardB are used for double buffering whereby one Board is the current board,
 	// and the other is the next generation. This allows for efficient updates without needing
 	// to copy the entire grid each generation.
-	BoardA board.InfiniteGrid
-	BoardB board.InfiniteGrid
+	BoardA board.Board
+	BoardB board.Board
 
 	// UseA indicates which board is currently active.
 	UseA bool
 
 	// Turn is the current turn number.
 	Turn int
+
+	// Rule is the birth/survival rule applied each generation.
+	// A nil Rule means Conway's Game of Life (B3/S23).
+	Rule *rule.Rule
+
+	// Topology bounds the grid, taking precedence over the rule's topology suffix.
+	// The zero Topology leaves the choice to the rule.
+	Topology board.Topology
+
+	// History records the generations the game goes through, so Seek and Rewind
+	// can go back to them. A nil History records nothing.
+	History *History
+
+	// hashLife holds the HashLife universe and node cache used by FastForward.
+	hashLife *hashlife.Universe
+
+	// gpu holds the GPU session that keeps the pattern on the device between generations.
+	gpu gpuState
+
+	// auto holds the auto backend's choice of backend for the game.
+	auto autoState
+
+	// tracker remembers the tiles that changed in the last generation of TickTracked.
+	tracker *changeTracker
+
+	// neighborConfigs is TickCpu's scratch map, kept so later generations reuse its storage.
+	neighborConfigs map[[2]int]uint8
 }
 
-func (g *Game) CurrentBoard() *board.InfiniteGrid {
-	if g.UseA {
-		return &g.BoardA
+// ActiveRule returns the rule in effect, defaulting to Conway's Game of Life.
+func (g *Game) ActiveRule() *rule.Rule {
+	if g.Rule == nil {
+		return rule.Conway
 	}
-	return &g.BoardB
+	return g.Rule
 }
 
-// Tick advances the game by one generation, applying the Game of Life rules.
-func (g *Game) Tick() {
-	// If BoardA is InfiniteGrid, use infinite tick logic
-	// keeping this here in case we implement a bitboard and want to toggle board implementations
-	g.TickInfinite()
+// Close frees what the game holds besides its boards: the GPU session, the
+// HashLife cache and the history. Call it before dropping or replacing a game
+// that may have run on a device. A closed game can still be played, starting
+// those over as needed, but no longer records a History.
+func (g *Game) Close() {
+	g.closeGpu()
+	g.hashLife = nil
+	g.tracker = nil
+	g.History = nil
 }
 
-// TickInfinite advances the game by one generation for InfiniteGrid.
-func (g *Game) TickInfinite() {
-	var src, dst *board.InfiniteGrid
+func (g *Game) CurrentBoard() board.Board {
 	if g.UseA {
-		src = &g.BoardA
-		dst = &g.BoardB
-	} else {
-		src = &g.BoardB
-		dst = &g.BoardA
+		return g.BoardA
+	}
+	return g.BoardB
+}
+
+// SetCell sets a cell of the current board. Edits made through it are seen by the
+// GPU session and TickTracked, which otherwise assume the board only changes from
+// one generation to the next, and make the auto backend choose again. They also
+// replace the generations after the current one in the History.
+func (g *Game) SetCell(row, col int, val board.Cell) {
+	g.CurrentBoard().Set(row, col, val)
+	g.closeGpu()
+	g.auto.next = g.Turn
+	if g.History != nil {
+		g.History.edited = true
 	}
+	if g.tracker != nil {
+		g.tracker.changed[trackTile(row, col)] = struct{}{}
+	}
+}
 
-	if UseGpu {
-		g.TickGpu(src, dst)
-	} else {
-		g.TickCpu(src, dst)
+// buffers returns the current board and the board the next generation is written to.
+func (g *Game) buffers() (src, dst board.Board) {
+	if g.UseA {
+		return g.BoardA, g.BoardB
 	}
+	return g.BoardB, g.BoardA
+}
+
+// Tick advances the game by one generation, applying the game's rule with the
+// backend chosen with SetBackend, or the CPU one if it doesn't support the game.
+func (g *Game) Tick() {
+	src, dst := g.buffers()
+	g.backend().Tick(g, src, dst)
 
 	g.UseA = !g.UseA
 	g.Turn++
+	g.recordHistory(g.Turn-1, src)
 }
 
-func (g *Game) TickGpu(src, dst *board.InfiniteGrid) {
-	gpu.Tick(src, dst)
+// genericTick reports whether TickCpu would take its generic per-cell path, which
+// TickParallel and TickTracked compute the same way.
+func (g *Game) genericTick(src, dst board.Board) bool {
+	r := g.ActiveRule()
+	if r.LtL != nil || r.Neighborhood.IsTriangular() || g.ActiveTopology().Bounded() {
+		return false
+	}
+	if s, ok := src.(*board.TiledGrid); ok {
+		if _, ok := dst.(*board.TiledGrid); ok && canTickTiled(r, g.ActiveTopology(), s) {
+			return false
+		}
+	}
+	return true
 }
 
-func (g *Game) TickCpu(src, dst *board.InfiniteGrid) {
+func (g *Game) TickCpu(src, dst board.Board) {
+	r := g.ActiveRule()
+	if r.LtL != nil {
+		g.TickLtL(src, dst)
+		return
+	}
+	if g.ActiveTopology().Bounded() {
+		g.TickBounded(src, dst)
+		return
+	}
+	if r.Neighborhood.IsTriangular() {
+		g.TickTriangular(src, dst)
+		return
+	}
+	if s, ok := src.(*board.TiledGrid); ok {
+		if d, ok := dst.(*board.TiledGrid); ok && canTickTiled(r, g.ActiveTopology(), s) {
+			g.TickTiled(s, d)
+			return
+		}
+	}
+
 	// Clear destination
-	dst.Cells = make(map[[2]int]board.Cell)
-	neighborCounts := make(map[[2]int]int)
-
-	// Count neighbors for all live cells and their neighbors
-	for pos := range src.Cells {
-		r, c := pos[0], pos[1]
-		for dr := -1; dr <= 1; dr++ {
-			for dc := -1; dc <= 1; dc++ {
-				if dr == 0 && dc == 0 {
-					continue
-				}
-				npos := [2]int{r + dr, c + dc}
-				neighborCounts[npos]++
-			}
+	dst.Clear()
+	// Neighborhood configuration of every cell that may be alive next generation,
+	// so isotropic non-totalistic rules can tell neighbor arrangements apart
+	if g.neighborConfigs == nil {
+		g.neighborConfigs = make(map[[2]int]uint8)
+	}
+	neighborConfigs := g.neighborConfigs
+	clear(neighborConfigs)
+
+	// Record neighbors for all live cells and their neighbors
+	for pos, state := range src.All() {
+		// Make sure isolated and dying cells are still considered
+		if _, ok := neighborConfigs[pos]; !ok {
+			neighborConfigs[pos] = 0
+		}
+		if !state.IsAlive() {
+			continue
+		}
+		row, col := pos[0], pos[1]
+		for i, d := range rule.Neighbors {
+			npos := [2]int{row + d[0], col + d[1]}
+			// This cell is the opposite neighbor from npos's point of view
+			neighborConfigs[npos] |= 1 << (7 - i)
 		}
 	}
 
 	// Apply rules
-	for pos, count := range neighborCounts {
-		alive := src.Cells[pos]
-		var next board.Cell
-		if alive {
-			next = count == 2 || count == 3
-		} else {
-			next = count == 3
-		}
-		if next {
-			dst.Cells[pos] = true
+	for pos, config := range neighborConfigs {
+		if next := r.NextConfig(src.At(pos[0], pos[1]), config); next != board.Dead {
+			dst.Set(pos[0], pos[1], next)
 		}
 	}
 }
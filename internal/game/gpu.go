@@ -0,0 +1,77 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/gpu"
+)
+
+// gpuState ties a device session to the backend that opened it and the
+// generation it holds: the board that generation was last written to, and its turn.
+type gpuState struct {
+	session *gpu.Session
+	backend *deviceBackend
+	board   board.Board
+	turn    int
+}
+
+// gpuSession returns a session of a device backend holding the current generation,
+// reusing the open one unless the game has moved on without it.
+func (g *Game) gpuSession(b *deviceBackend, src board.Board) (*gpu.Session, error) {
+	r, t := g.ActiveRule(), g.ActiveTopology()
+	if s := g.gpu.session; s != nil && g.gpu.backend == b && g.gpu.board == src && g.gpu.turn == g.Turn && s.Rule() == r && s.Topology() == t {
+		return s, nil
+	}
+	g.closeGpu()
+	s, err := gpu.NewSession(b.newDevice(), src, r, t)
+	if err != nil {
+		return nil, err
+	}
+	g.gpu = gpuState{session: s, backend: b, board: src, turn: g.Turn}
+	return s, nil
+}
+
+// storeGpu downloads the generation n turns after the current one from the
+// session to dst, which becomes the session's board.
+func (g *Game) storeGpu(dst board.Board, n int) error {
+	if err := g.gpu.session.Store(dst); err != nil {
+		return err
+	}
+	g.gpu.board, g.gpu.turn = dst, g.Turn+n
+	return nil
+}
+
+// closeGpu frees the GPU session, if there is one.
+func (g *Game) closeGpu() {
+	if g.gpu.session != nil {
+		g.gpu.session.Close()
+	}
+	g.gpu = gpuState{}
+}
+
+// tickDevice computes the next generation on a device backend. The pattern stays
+// on the device, so consecutive ticks only download it. If the device fails, the
+// failure is logged and the generation is computed by TickCpu instead.
+func (g *Game) tickDevice(b *deviceBackend, src, dst board.Board) {
+	s, err := g.gpuSession(b, src)
+	if err == nil {
+		err = s.Step(1)
+	}
+	if err == nil {
+		err = g.storeGpu(dst, 1)
+	}
+	if err != nil {
+		g.closeGpu()
+		g.fallBack(b, 1, err)
+		g.TickCpu(src, dst)
+	}
+}
+
+// fallBack logs that a device backend failed to compute the next n generations,
+// which are computed on the CPU instead.
+func (g *Game) fallBack(b *deviceBackend, n int, err error) {
+	if n == 1 {
+		Logf("%s backend failed, computing generation %d on the CPU: %v", b.name, g.Turn+1, err)
+	} else {
+		Logf("%s backend failed, computing generations %d to %d on the CPU: %v", b.name, g.Turn+1, g.Turn+n, err)
+	}
+}
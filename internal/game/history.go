@@ -0,0 +1,239 @@
+package game
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// keyframeEvery is the number of generations between two full copies of the
+// board in a History. The generations in between are stored as the cells that
+// changed, so seeking replays at most keyframeEvery-1 of them.
+const keyframeEvery = 64
+
+// History records the generations a Game goes through, so it can Seek back to
+// them. It keeps a full copy of the board every keyframeEvery generations, and
+// the changed cells of the generations in between.
+//
+// Generations that Advance or FastForward skip over aren't stored: seeking to one
+// computes it again from the generation before, which is why a History assumes
+// the game's rule and topology don't change.
+type History struct {
+	// blocks are the recorded generations, oldest first.
+	blocks []historyBlock
+	// limit is the number of generations kept, and length the number stored.
+	limit, length int
+	// edited is set when a cell of the current generation was changed by hand,
+	// so its recorded state no longer holds.
+	edited bool
+}
+
+// historyBlock is a keyframe and the generations that follow it one by one.
+type historyBlock struct {
+	turn     int
+	keyframe board.Board
+	// edited is set when the keyframe was edited by hand, so it doesn't follow
+	// from the generation before.
+	edited bool
+	// deltas[i] turns generation turn+i into generation turn+i+1.
+	deltas []historyDelta
+}
+
+// historyDelta holds the cells that change from one generation to the next, with
+// their new states.
+type historyDelta []historyChange
+
+type historyChange struct {
+	pos   [2]int
+	state board.Cell
+}
+
+// NewHistory returns a History that keeps about the last limit generations: the
+// oldest are dropped a keyframe's worth at a time.
+func NewHistory(limit int) *History {
+	return &History{limit: max(limit, 1)}
+}
+
+// Len returns the number of generations stored. Len, Oldest and Newest may be
+// called on a nil History, which stores none.
+func (h *History) Len() int {
+	if h == nil {
+		return 0
+	}
+	return h.length
+}
+
+// Oldest and Newest return the first and last generations that can be sought to,
+// or 0 if nothing is recorded yet.
+func (h *History) Oldest() int {
+	if h == nil || len(h.blocks) == 0 {
+		return 0
+	}
+	return h.blocks[0].turn
+}
+
+func (h *History) Newest() int {
+	if h == nil || len(h.blocks) == 0 {
+		return 0
+	}
+	b := &h.blocks[len(h.blocks)-1]
+	return b.last()
+}
+
+// last returns the turn of the block's last generation.
+func (b *historyBlock) last() int {
+	return b.turn + len(b.deltas)
+}
+
+// truncate forgets the generations from turn on.
+func (h *History) truncate(turn int) {
+	for len(h.blocks) > 0 {
+		b := &h.blocks[len(h.blocks)-1]
+		switch {
+		case b.turn >= turn:
+			h.length -= len(b.deltas) + 1
+			h.blocks = h.blocks[:len(h.blocks)-1]
+		case b.last() >= turn:
+			h.length -= b.last() - turn + 1
+			b.deltas = b.deltas[:turn-b.turn-1]
+			return
+		default:
+			return
+		}
+	}
+}
+
+// keyframe starts a block with a copy of b as generation turn.
+func (h *History) keyframe(turn int, b board.Board, edited bool) {
+	h.blocks = append(h.blocks, historyBlock{turn: turn, keyframe: b.Clone(), edited: edited})
+	h.length++
+}
+
+// editedIn reports whether a generation after from and up to to was edited by hand.
+func (h *History) editedIn(from, to int) bool {
+	for i := len(h.blocks) - 1; i >= 0 && h.blocks[i].turn > from; i-- {
+		if h.blocks[i].edited && h.blocks[i].turn <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// record stores the generation the game just moved to, dst at turn, which follows
+// src at turn prev.
+func (h *History) record(prev int, src board.Board, turn int, dst board.Board) {
+	if !h.edited && len(h.blocks) > 0 && h.Oldest() <= prev && prev <= h.Newest() {
+		if turn <= h.Newest() && !h.editedIn(prev, turn) {
+			// Already recorded: the game was sought back and is replaying it
+			return
+		}
+		// The game was sought back to before an edit, or past the end: what was
+		// recorded after prev no longer follows from it
+		h.truncate(prev + 1)
+	}
+	if h.edited || len(h.blocks) == 0 || h.Newest() != prev {
+		h.truncate(prev)
+		h.keyframe(prev, src, h.edited)
+		h.edited = false
+	}
+
+	b := &h.blocks[len(h.blocks)-1]
+	if turn == prev+1 && b.last() == prev && len(b.deltas) < keyframeEvery-1 {
+		b.deltas = append(b.deltas, diff(src, dst))
+		h.length++
+	} else {
+		// Skipped generations, or a block that is full
+		h.keyframe(turn, dst, false)
+	}
+
+	for len(h.blocks) > 1 && h.length-len(h.blocks[0].deltas)-1 >= h.limit {
+		h.length -= len(h.blocks[0].deltas) + 1
+		h.blocks[0] = historyBlock{}
+		h.blocks = h.blocks[1:]
+	}
+}
+
+// diff returns the cells that differ between src and dst, with their states in dst.
+func diff(src, dst board.Board) historyDelta {
+	var d historyDelta
+	for pos, state := range dst.All() {
+		if src.At(pos[0], pos[1]) != state {
+			d = append(d, historyChange{pos, state})
+		}
+	}
+	for pos := range src.All() {
+		if dst.At(pos[0], pos[1]) == board.Dead {
+			d = append(d, historyChange{pos, board.Dead})
+		}
+	}
+	return d
+}
+
+// restore writes the latest recorded generation up to turn to dst, and returns
+// its turn.
+func (h *History) restore(turn int, dst board.Board) int {
+	i := len(h.blocks) - 1
+	for i > 0 && h.blocks[i].turn > turn {
+		i--
+	}
+	b := &h.blocks[i]
+	dst.Clear()
+	for pos, state := range b.keyframe.All() {
+		dst.Set(pos[0], pos[1], state)
+	}
+	n := min(turn, b.last()) - b.turn
+	for _, d := range b.deltas[:n] {
+		for _, c := range d {
+			dst.Set(c.pos[0], c.pos[1], c.state)
+		}
+	}
+	return b.turn + n
+}
+
+// recordHistory adds the generation the game just moved to, from src at turn prev,
+// to its History, if it has one.
+func (g *Game) recordHistory(prev int, src board.Board) {
+	if g.History != nil {
+		g.History.record(prev, src, g.Turn, g.CurrentBoard())
+	}
+}
+
+// Seek moves the game to a generation recorded in its History, between
+// History.Oldest and History.Newest. Generations that weren't stored one by one
+// are computed again from the closest one before that was.
+func (g *Game) Seek(gen int) error {
+	h := g.History
+	if h.Len() == 0 {
+		return fmt.Errorf("no history to seek in")
+	}
+	if h.edited {
+		// The current generation was edited, so the generations after it are lost
+		h.truncate(g.Turn)
+		h.keyframe(g.Turn, g.CurrentBoard(), true)
+		h.edited = false
+	}
+	if gen < h.Oldest() || gen > h.Newest() {
+		return fmt.Errorf("generation %d is not in the history, which holds generations %d to %d", gen, h.Oldest(), h.Newest())
+	}
+
+	// The board no longer follows on from the generation the GPU session and
+	// TickTracked last saw
+	g.closeGpu()
+	if g.tracker != nil {
+		g.tracker.dst = nil
+	}
+	g.auto.next = gen
+
+	src, _ := g.buffers()
+	g.Turn = h.restore(gen, src)
+	if g.Turn < gen {
+		return g.Advance(context.Background(), gen-g.Turn, nil)
+	}
+	return nil
+}
+
+// Rewind moves the game n generations back in its History (see Seek).
+func (g *Game) Rewind(n int) error {
+	return g.Seek(g.Turn - n)
+}
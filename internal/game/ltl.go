@@ -0,0 +1,157 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// ltlTile is the smallest side of the tiles TickLtL splits a sparse pattern into.
+const ltlTile = 64
+
+// TickLtL advances a Larger than Life rule by one generation.
+// Rather than visiting the whole range-R neighborhood of every cell, it builds a
+// summed-area table of the live cells over the bounding box padded by R, so a Moore
+// count is four table lookups and a von Neumann count is four lookups per row of the diamond.
+// On a bounded grid the box is the whole grid, and the padding is filled through the topology.
+// A pattern whose box is mostly empty, such as two cells far apart, gets a table
+// per tile of live cells instead, so the memory used follows the population.
+func (g *Game) TickLtL(src, dst board.Board) {
+	t := g.ActiveTopology()
+	radius := g.ActiveRule().LtL.Range
+
+	// Clear destination
+	dst.Clear()
+	if src.Len() == 0 {
+		return
+	}
+
+	if t.Bounded() {
+		minRow, minCol, maxRow, maxCol := t.Extent()
+		g.tickLtLWindow(src, dst, minRow, minCol, maxRow, maxCol, func(set func(row, col int)) {
+			for row := minRow - radius; row <= maxRow+radius; row++ {
+				for col := minCol - radius; col <= maxCol+radius; col++ {
+					wr, wc, ok := t.Wrap(row, col)
+					if ok && src.At(wr, wc).IsAlive() {
+						set(row, col)
+					}
+				}
+			}
+		})
+		return
+	}
+
+	// The live cells by tile, and the tiles within reach of them. Tiles are at
+	// least R wide, so cells can only be born in the tiles next to live ones.
+	size := max(ltlTile, radius)
+	live := make(map[[2]int][][2]int)
+	for pos, state := range src.All() {
+		if state.IsAlive() {
+			tile := [2]int{floorDiv(pos[0], size), floorDiv(pos[1], size)}
+			live[tile] = append(live[tile], pos)
+		}
+	}
+	reach := make(map[[2]int]struct{})
+	for tile := range live {
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				reach[[2]int{tile[0] + dr, tile[1] + dc}] = struct{}{}
+			}
+		}
+	}
+
+	minRow, minCol, maxRow, maxCol := src.Bounds()
+	boxArea := float64(maxRow-minRow+1+4*radius) * float64(maxCol-minCol+1+4*radius)
+	tileArea := float64(size+2*radius) * float64(size+2*radius)
+	if boxArea <= 2*float64(len(reach))*tileArea {
+		g.tickLtLWindow(src, dst, minRow-radius, minCol-radius, maxRow+radius, maxCol+radius, func(set func(row, col int)) {
+			for _, cells := range live {
+				for _, pos := range cells {
+					set(pos[0], pos[1])
+				}
+			}
+		})
+		return
+	}
+	for tile := range reach {
+		row, col := tile[0]*size, tile[1]*size
+		g.tickLtLWindow(src, dst, row, col, row+size-1, col+size-1, func(set func(row, col int)) {
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					for _, pos := range live[[2]int{tile[0] + dr, tile[1] + dc}] {
+						set(pos[0], pos[1])
+					}
+				}
+			}
+		})
+	}
+}
+
+// tickLtLWindow computes the next generation of the cells from row r0 and column
+// c0 to row r1 and column c1, with a summed-area table over them padded by R.
+// fill calls set for the live cells of the padded window, in board coordinates,
+// with those past the padding ignored; on a bounded grid that includes the cells
+// wrapped into the padding.
+func (g *Game) tickLtLWindow(src, dst board.Board, r0, c0, r1, c1 int, fill func(set func(row, col int))) {
+	r := g.ActiveRule()
+	radius := r.LtL.Range
+	minRow, minCol := r0-radius, c0-radius
+	rows := r1 - r0 + 1 + 2*radius
+	cols := c1 - c0 + 1 + 2*radius
+
+	// sat[(row+1)*stride+(col+1)] is the number of live cells in rows 0..row and cols 0..col
+	stride := cols + 1
+	sat := make([]int32, (rows+1)*stride)
+	fill(func(row, col int) {
+		row, col = row-minRow, col-minCol
+		if row >= 0 && row < rows && col >= 0 && col < cols {
+			sat[(row+1)*stride+col+1] = 1
+		}
+	})
+	for row := 1; row <= rows; row++ {
+		for col := 1; col <= cols; col++ {
+			i := row*stride + col
+			sat[i] += sat[i-stride] + sat[i-1] - sat[i-stride-1]
+		}
+	}
+
+	// rect counts the live cells in the inclusive rectangle, clipped to the padded window
+	rect := func(r0, c0, r1, c1 int) int {
+		r0, c0 = max(r0, 0), max(c0, 0)
+		r1, c1 = min(r1, rows-1), min(c1, cols-1)
+		if r0 > r1 || c0 > c1 {
+			return 0
+		}
+		return int(sat[(r1+1)*stride+c1+1] - sat[r0*stride+c1+1] - sat[(r1+1)*stride+c0] + sat[r0*stride+c0])
+	}
+
+	// Only the cells of the window are evaluated, not its padding
+	for row := radius; row < rows-radius; row++ {
+		for col := radius; col < cols-radius; col++ {
+			var count int
+			if r.Neighborhood == rule.VonNeumann {
+				for dr := -radius; dr <= radius; dr++ {
+					w := radius - abs(dr)
+					count += rect(row+dr, col-w, row+dr, col+w)
+				}
+			} else {
+				count = rect(row-radius, col-radius, row+radius, col+radius)
+			}
+
+			pos := [2]int{row + minRow, col + minCol}
+			state := src.At(pos[0], pos[1])
+			if state.IsAlive() && !r.LtL.Middle {
+				count--
+			}
+			if next := r.Next(state, count); next != board.Dead {
+				dst.Set(pos[0], pos[1], next)
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// bandCell is a cell of the current generation handed to the worker of a band.
+type bandCell struct {
+	pos   [2]int
+	state board.Cell
+}
+
+// TickParallel computes the same generation as TickCpu with several goroutines.
+// The board is cut into horizontal bands of rows, one per worker. Each worker gets
+// the live cells of its band and of the rows bordering it, builds the neighborhood
+// configurations of its own rows in a private map and collects the surviving cells.
+// The results are written to dst band by band, sorted, so the order of Set calls
+// does not depend on scheduling.
+// The rule must be supported (see genericTick).
+func (g *Game) TickParallel(src, dst board.Board, workers int) {
+	r := g.ActiveRule()
+	dst.Clear()
+	if src.Len() == 0 {
+		return
+	}
+
+	// Cells may be born one row above or below the pattern
+	minRow, _, maxRow, _ := src.Bounds()
+	minRow, maxRow = minRow-1, maxRow+1
+	height := maxRow - minRow + 1
+	workers = max(1, min(workers, height))
+	bandHeight := (height + workers - 1) / workers
+	workers = (height + bandHeight - 1) / bandHeight
+
+	// Split the cells, copying those on a band edge to the neighboring band too
+	inputs := make([][]bandCell, workers)
+	for pos, state := range src.All() {
+		b := (pos[0] - minRow) / bandHeight
+		inputs[b] = append(inputs[b], bandCell{pos, state})
+		if !state.IsAlive() {
+			continue
+		}
+		offset := (pos[0] - minRow) % bandHeight
+		if offset == 0 && b > 0 {
+			inputs[b-1] = append(inputs[b-1], bandCell{pos, state})
+		}
+		if offset == bandHeight-1 && b < workers-1 {
+			inputs[b+1] = append(inputs[b+1], bandCell{pos, state})
+		}
+	}
+
+	outputs := make([][]bandCell, workers)
+	var wg sync.WaitGroup
+	for b := range workers {
+		lo := minRow + b*bandHeight
+		hi := lo + bandHeight
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outputs[b] = tickBand(r, inputs[b], lo, hi)
+		}()
+	}
+	wg.Wait()
+
+	for _, out := range outputs {
+		for _, c := range out {
+			dst.Set(c.pos[0], c.pos[1], c.state)
+		}
+	}
+}
+
+// tickBand returns the next generation of the rows lo to hi-1, sorted by position,
+// given the cells of those rows and the live cells of the rows next to them.
+func tickBand(r *rule.Rule, cells []bandCell, lo, hi int) []bandCell {
+	type entry struct {
+		config uint8
+		state  board.Cell
+	}
+	entries := make(map[[2]int]entry, len(cells)*2)
+	for _, c := range cells {
+		row, col := c.pos[0], c.pos[1]
+		if row >= lo && row < hi {
+			// Make sure isolated and dying cells are still considered
+			e := entries[c.pos]
+			e.state = c.state
+			entries[c.pos] = e
+		}
+		if !c.state.IsAlive() {
+			continue
+		}
+		for i, d := range rule.Neighbors {
+			npos := [2]int{row + d[0], col + d[1]}
+			if npos[0] < lo || npos[0] >= hi {
+				continue
+			}
+			// This cell is the opposite neighbor from npos's point of view
+			e := entries[npos]
+			e.config |= 1 << (7 - i)
+			entries[npos] = e
+		}
+	}
+
+	out := make([]bandCell, 0, len(cells))
+	for pos, e := range entries {
+		if next := r.NextConfig(e.state, e.config); next != board.Dead {
+			out = append(out, bandCell{pos, next})
+		}
+	}
+	slices.SortFunc(out, func(a, b bandCell) int {
+		if a.pos[0] != b.pos[0] {
+			return a.pos[0] - b.pos[0]
+		}
+		return a.pos[1] - b.pos[1]
+	})
+	return out
+}
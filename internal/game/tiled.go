@@ -0,0 +1,21 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// canTickTiled reports whether a generation can be computed with the bit-parallel
+// tick of the tiled board: a two-state totalistic rule on the Moore or hexagonal
+// neighborhood of an unbounded grid, from a board holding only live cells.
+func canTickTiled(r *rule.Rule, t board.Topology, src *board.TiledGrid) bool {
+	return r.LtL == nil && r.States == 2 && !r.Isotropic &&
+		r.Neighborhood.ConfigMask() != 0 && !t.Bounded() && src.TwoState()
+}
+
+// TickTiled advances two tiled boards by one generation, 64 cells at a time.
+// The rule must be supported (see canTickTiled).
+func (g *Game) TickTiled(src, dst *board.TiledGrid) {
+	r := g.ActiveRule()
+	src.StepLife(dst, r.Birth, r.Survive, r.Neighborhood.ConfigMask())
+}
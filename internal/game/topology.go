@@ -0,0 +1,81 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// ActiveTopology returns the grid in effect: the game's Topology if it is bounded,
+// otherwise the one given by the rule's suffix.
+func (g *Game) ActiveTopology() board.Topology {
+	if g.Topology.Bounded() {
+		return g.Topology
+	}
+	return g.ActiveRule().Topology
+}
+
+// TickBounded advances a B/S rule on a bounded grid by one generation.
+// Each candidate cell gathers its own neighborhood through the topology rather than
+// having live cells scatter to their neighbors, because a cell seen across a reversed
+// edge is mirrored and the two cells disagree on which side of each other they lie.
+// Cells outside the grid are dropped.
+func (g *Game) TickBounded(src, dst board.Board) {
+	r := g.ActiveRule()
+	t := g.ActiveTopology()
+
+	// Clear destination
+	dst.Clear()
+
+	alive := func(row, col int) bool {
+		row, col, ok := t.Wrap(row, col)
+		return ok && src.At(row, col).IsAlive()
+	}
+	neighbors := func(row, col int) [][2]int {
+		if r.Neighborhood.IsTriangular() {
+			return rule.TriangleNeighbors(r.Neighborhood, row, col)
+		}
+		return rule.Neighbors[:]
+	}
+
+	// Every cell on the grid that is not dead or has a live neighbor
+	candidates := make(map[[2]int]struct{})
+	for pos, state := range src.All() {
+		if !t.Contains(pos[0], pos[1]) {
+			continue
+		}
+		candidates[pos] = struct{}{}
+		if !state.IsAlive() {
+			continue
+		}
+		for _, d := range neighbors(pos[0], pos[1]) {
+			if row, col, ok := t.Wrap(pos[0]+d[0], pos[1]+d[1]); ok {
+				candidates[[2]int{row, col}] = struct{}{}
+			}
+		}
+	}
+
+	// Apply rules
+	for pos := range candidates {
+		var next board.Cell
+		if r.Neighborhood.IsTriangular() {
+			count := 0
+			for _, d := range neighbors(pos[0], pos[1]) {
+				if alive(pos[0]+d[0], pos[1]+d[1]) {
+					count++
+				}
+			}
+			next = r.Next(src.At(pos[0], pos[1]), count)
+		} else {
+			var config uint8
+			for i, d := range rule.Neighbors {
+				if alive(pos[0]+d[0], pos[1]+d[1]) {
+					config |= 1 << i
+				}
+			}
+			next = r.NextConfig(src.At(pos[0], pos[1]), config)
+		}
+		if next != board.Dead {
+			dst.Set(pos[0], pos[1], next)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// trackTileSize is the side of the square tiles TickTracked tracks changes in.
+const trackTileSize = 32
+
+// changeTracker holds what TickTracked knows about the last generation it computed.
+type changeTracker struct {
+	// dst and turn identify that generation: the board it was written to and its turn.
+	dst  board.Board
+	turn int
+	// rule and topology are what it was computed under.
+	rule     *rule.Rule
+	topology board.Topology
+
+	// changed holds the tiles in which some cell differs from the generation before.
+	changed map[[2]int]struct{}
+
+	// Scratch storage reused across generations
+	next    map[[2]int]struct{}
+	eval    map[[2]int]struct{}
+	configs map[[2]int]uint8
+}
+
+func trackTile(row, col int) [2]int {
+	return [2]int{floorDiv(row, trackTileSize), floorDiv(col, trackTileSize)}
+}
+
+// TickTracked computes the same generation as TickCpu, but only evaluates the tiles
+// that changed in the previous generation and the tiles around them. Every other
+// tile saw the same neighborhood as last generation, so it keeps its cells, which
+// are copied over: still lifes and settled debris cost a copy instead of a count.
+// It relies on Tick to advance the turn, and on edits going through SetCell;
+// when the board doesn't follow on from its last generation, or the rule or
+// topology changed since, the whole board is evaluated once.
+// The rule must be supported (see genericTick).
+func (g *Game) TickTracked(src, dst board.Board) {
+	r := g.ActiveRule()
+	t := g.tracker
+	if t == nil {
+		t = &changeTracker{
+			changed: make(map[[2]int]struct{}),
+			next:    make(map[[2]int]struct{}),
+			eval:    make(map[[2]int]struct{}),
+			configs: make(map[[2]int]uint8),
+		}
+		g.tracker = t
+	}
+	topo := g.ActiveTopology()
+	full := t.dst != src || t.turn != g.Turn || t.rule != r || t.topology != topo
+
+	// Tiles to evaluate: the changed ones and their neighbors
+	eval := t.eval
+	clear(eval)
+	if !full {
+		for key := range t.changed {
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					eval[[2]int{key[0] + dr, key[1] + dc}] = struct{}{}
+				}
+			}
+		}
+	}
+	evaluated := func(row, col int) bool {
+		if full {
+			return true
+		}
+		_, ok := eval[trackTile(row, col)]
+		return ok
+	}
+
+	dst.Clear()
+	configs := t.configs
+	clear(configs)
+	for pos, state := range src.All() {
+		row, col := pos[0], pos[1]
+		tr, tc := floorDiv(row, trackTileSize), floorDiv(col, trackTileSize)
+		_, ok := eval[[2]int{tr, tc}]
+		inside := full || ok
+		// The neighbors of a cell off the tile edges are in its own tile
+		edge := row == tr*trackTileSize || row == (tr+1)*trackTileSize-1 ||
+			col == tc*trackTileSize || col == (tc+1)*trackTileSize-1
+
+		if !inside {
+			// The tile is settled, so the cell carries over
+			dst.Set(row, col, state)
+			if !state.IsAlive() || !edge {
+				continue
+			}
+		} else if _, ok := configs[pos]; !ok {
+			// Make sure isolated and dying cells are still considered
+			configs[pos] = 0
+		}
+		if !state.IsAlive() {
+			continue
+		}
+		for i, d := range rule.Neighbors {
+			npos := [2]int{row + d[0], col + d[1]}
+			if edge && !evaluated(npos[0], npos[1]) {
+				continue
+			}
+			// This cell is the opposite neighbor from npos's point of view
+			configs[npos] |= 1 << (7 - i)
+		}
+	}
+
+	// Apply rules, noting the tiles whose cells change
+	changed := t.next
+	clear(changed)
+	for pos, config := range configs {
+		state := src.At(pos[0], pos[1])
+		next := r.NextConfig(state, config)
+		if next != board.Dead {
+			dst.Set(pos[0], pos[1], next)
+		}
+		if next != state {
+			changed[trackTile(pos[0], pos[1])] = struct{}{}
+		}
+	}
+	t.changed, t.next = changed, t.changed
+	t.dst, t.turn = dst, g.Turn+1
+	t.rule, t.topology = r, topo
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
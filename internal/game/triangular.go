@@ -0,0 +1,39 @@
+package game
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// TickTriangular advances a rule on a triangular grid by one generation.
+// Up- and down-pointing triangles have mirrored neighborhoods, so neighbors are
+// counted per cell orientation rather than with the fixed Moore offsets.
+func (g *Game) TickTriangular(src, dst board.Board) {
+	r := g.ActiveRule()
+
+	// Clear destination
+	dst.Clear()
+	neighborCounts := make(map[[2]int]int)
+
+	// Count neighbors for all live cells and their neighbors
+	for pos, state := range src.All() {
+		// Make sure isolated and dying cells are still considered
+		if _, ok := neighborCounts[pos]; !ok {
+			neighborCounts[pos] = 0
+		}
+		if !state.IsAlive() {
+			continue
+		}
+		row, col := pos[0], pos[1]
+		for _, d := range rule.TriangleNeighbors(r.Neighborhood, row, col) {
+			neighborCounts[[2]int{row + d[0], col + d[1]}]++
+		}
+	}
+
+	// Apply rules
+	for pos, count := range neighborCounts {
+		if next := r.Next(src.At(pos[0], pos[1]), count); next != board.Dead {
+			dst.Set(pos[0], pos[1], next)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package gpu
+
+import (
+	"fmt"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// CPUDevice is a Device that runs the tick kernel's computation on the CPU, one
+// cell at a time. It stands in for the GPU in tests and on machines without one,
+// so the Session bookkeeping around the kernel can be checked anywhere.
+type CPUDevice struct {
+	cur, next  []int32
+	rows, cols int
+	table      [512]uint8
+	states     int
+	topo       board.Topology
+
+	// row0 and col0 are the board position of the grid's upper-left cell on a
+	// bounded grid, where neighbors are wrapped with topo.Wrap.
+	row0, col0 int
+
+	// MaxCells, when positive, is the number of cells the device has memory for,
+	// counting both buffers of a grid or the input and output of a batch of tiles.
+	// Larger requests fail with ErrOutOfMemory, as on a GPU.
+	MaxCells int
+}
+
+func NewCPUDevice() Device {
+	return &CPUDevice{}
+}
+
+func (d *CPUDevice) Load(cells []int32, rows, cols int, table [512]uint8, states int, t board.Topology) error {
+	d.Close()
+	if d.MaxCells > 0 && 2*rows*cols > d.MaxCells {
+		return fmt.Errorf("upload: %dx%d grid: %w", rows, cols, ErrOutOfMemory)
+	}
+	d.cur = append(d.cur[:0], cells[:rows*cols]...)
+	d.next = make([]int32, rows*cols)
+	d.rows, d.cols = rows, cols
+	d.table, d.states, d.topo = table, states, t
+	d.row0, d.col0, _, _ = t.Extent()
+	return nil
+}
+
+func (d *CPUDevice) Step(n int) error {
+	if d.cur == nil {
+		return fmt.Errorf("tick: %w", errNoGrid)
+	}
+	for ; n > 0; n-- {
+		for r := 0; r < d.rows; r++ {
+			for c := 0; c < d.cols; c++ {
+				d.next[r*d.cols+c] = d.nextState(r, c)
+			}
+		}
+		d.cur, d.next = d.next, d.cur
+	}
+	return nil
+}
+
+// nextState mirrors tick_cuda for the cell at (r, c).
+func (d *CPUDevice) nextState(r, c int) int32 {
+	var config uint8
+	for i, off := range rule.Neighbors {
+		rr, cc := r+off[0], c+off[1]
+		if d.topo.Bounded() {
+			row, col, ok := d.topo.Wrap(rr+d.row0, cc+d.col0)
+			if !ok {
+				continue
+			}
+			rr, cc = row-d.row0, col-d.col0
+		} else if rr < 0 || rr >= d.rows || cc < 0 || cc >= d.cols {
+			continue
+		}
+		if d.cur[rr*d.cols+cc] == 1 {
+			config |= 1 << i
+		}
+	}
+
+	return nextState(d.cur[r*d.cols+c], config, d.table, d.states)
+}
+
+// nextState mirrors next_state in gpu.cu.
+func nextState(cur int32, config uint8, table [512]uint8, states int) int32 {
+	switch {
+	case cur == 0:
+		return int32(table[config])
+	case cur == 1 && table[256+int(config)] != 0:
+		return 1
+	case int(cur)+1 < states:
+		return cur + 1
+	}
+	return 0
+}
+
+func (d *CPUDevice) BorderAlive(width int) (bool, error) {
+	for r := 0; r < d.rows; r++ {
+		for c := 0; c < d.cols; c++ {
+			edge := r < width || r >= d.rows-width || c < width || c >= d.cols-width
+			if edge && d.cur[r*d.cols+c] != 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (d *CPUDevice) Read(cells []int32) error {
+	if d.cur == nil {
+		return fmt.Errorf("download: %w", errNoGrid)
+	}
+	copy(cells, d.cur)
+	return nil
+}
+
+// StepTiles mirrors tick_tiles_cuda.
+func (d *CPUDevice) StepTiles(in, out []int32, count, size int, table [512]uint8, states int) error {
+	stride := size + 2
+	if d.MaxCells > 0 && count*(stride*stride+size*size) > d.MaxCells {
+		return fmt.Errorf("tick tiles: %d tiles: %w", count, ErrOutOfMemory)
+	}
+	for t := range count {
+		tile := in[t*stride*stride:]
+		for r := range size {
+			for c := range size {
+				var config uint8
+				for i, off := range rule.Neighbors {
+					if tile[(r+1+off[0])*stride+c+1+off[1]] == 1 {
+						config |= 1 << i
+					}
+				}
+				out[(t*size+r)*size+c] = nextState(tile[(r+1)*stride+c+1], config, table, states)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *CPUDevice) Close() {
+	d.cur, d.next = nil, nil
+}
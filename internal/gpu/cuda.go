@@ -0,0 +1,129 @@
+//go:build cgo && !nocuda
+
+package gpu
+
+/*
+#cgo CFLAGS: -I.
+#include "gpu.h"
+*/
+import "C"
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"unsafe"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// Initial demo only to get gpu stuff hooked up, not used
+func Square(a []float32) {
+	if loadKernels() != nil {
+		return
+	}
+	C.square((*C.float)(&a[0]), C.int(len(a)))
+}
+
+// cudaDevice is the Device of the CUDA kernel in gpu.cu.
+type cudaDevice struct {
+	h *sessionHandle
+}
+
+type sessionHandle struct {
+	s *C.struct_session
+}
+
+func (h *sessionHandle) free() {
+	if h.s != nil {
+		C.session_free(h.s)
+		h.s = nil
+	}
+}
+
+// NewCUDADevice returns a Device that runs on the GPU.
+func NewCUDADevice() Device {
+	d := &cudaDevice{h: &sessionHandle{}}
+	// Devices dropped without Close still give their memory back
+	runtime.AddCleanup(d, (*sessionHandle).free, d.h)
+	return d
+}
+
+func (d *cudaDevice) Load(cells []int32, rows, cols int, table [512]uint8, states int, t board.Topology) error {
+	d.Close()
+	// The kernel indexes the grid with an int
+	if rows*cols > math.MaxInt32 {
+		return fmt.Errorf("upload: %dx%d grid: %w", rows, cols, ErrOutOfMemory)
+	}
+	if err := loadKernels(); err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	var s *C.struct_session
+	code := C.session_new(&s,
+		(*C.int)(unsafe.Pointer(&cells[0])),
+		C.int(rows),
+		C.int(cols),
+		(*C.uchar)(unsafe.Pointer(&table[0])),
+		C.int(states),
+		topology(t),
+	)
+	if err := cudaError("upload", code); err != nil {
+		return err
+	}
+	d.h.s = s
+	return nil
+}
+
+func (d *cudaDevice) Step(n int) error {
+	if d.h.s == nil {
+		return fmt.Errorf("tick: %w", errNoGrid)
+	}
+	return cudaError("tick", C.session_step(d.h.s, C.int(n)))
+}
+
+func (d *cudaDevice) BorderAlive(width int) (bool, error) {
+	if d.h.s == nil {
+		return false, fmt.Errorf("border check: %w", errNoGrid)
+	}
+	var alive C.int
+	err := cudaError("border check", C.session_border_alive(d.h.s, C.int(width), &alive))
+	return alive != 0, err
+}
+
+func (d *cudaDevice) Read(cells []int32) error {
+	if d.h.s == nil {
+		return fmt.Errorf("download: %w", errNoGrid)
+	}
+	return cudaError("download", C.session_read(d.h.s, (*C.int)(unsafe.Pointer(&cells[0]))))
+}
+
+func (d *cudaDevice) StepTiles(in, out []int32, count, size int, table [512]uint8, states int) error {
+	if err := loadKernels(); err != nil {
+		return fmt.Errorf("tick tiles: %w", err)
+	}
+	code := C.tick_tiles(
+		(*C.int)(unsafe.Pointer(&in[0])),
+		(*C.int)(unsafe.Pointer(&out[0])),
+		C.int(count),
+		C.int(size),
+		(*C.uchar)(unsafe.Pointer(&table[0])),
+		C.int(states),
+	)
+	return cudaError("tick tiles", code)
+}
+
+func (d *cudaDevice) Close() {
+	d.h.free()
+}
+
+// topology converts a topology to the form the kernel uses.
+func topology(t board.Topology) C.struct_topology {
+	ct := C.struct_topology{
+		kind:      C.int(t.Kind),
+		shift_col: C.int(t.ShiftCol),
+		shift_row: C.int(t.ShiftRow),
+	}
+	if t.TwistRows {
+		ct.twist_rows = 1
+	}
+	return ct
+}
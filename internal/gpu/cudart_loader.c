@@ -1,3 +1,5 @@
+//go:build cgo && !nocuda && !fakecuda
+
 // cudart_loader.c
 #define _GNU_SOURCE
 #include <dlfcn.h>
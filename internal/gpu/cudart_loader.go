@@ -1,3 +1,5 @@
+//go:build cgo && !nocuda
+
 package gpu
 
 /*
@@ -5,26 +7,46 @@ package gpu
 #include "cudart_loader.h"
 */
 import "C"
-import "unsafe"
+import (
+	"fmt"
+	"unsafe"
+)
 
 // Go wrappers around the C _wrap functions.
+// They return nil on success, and otherwise an error carrying the CUDA error string.
+
+func CudaMalloc(devPtr *unsafe.Pointer, size uint64) error {
+	return cudaError("cudaMalloc", C.int(C.cudaMalloc_wrap(devPtr, C.size_t(size))))
+}
 
-func CudaMalloc(devPtr *unsafe.Pointer, size uint64) C.cudaError_t {
-	return C.cudaMalloc_wrap(devPtr, C.size_t(size))
+func CudaFree(devPtr unsafe.Pointer) error {
+	return cudaError("cudaFree", C.int(C.cudaFree_wrap(devPtr)))
 }
 
-func CudaFree(devPtr unsafe.Pointer) C.cudaError_t {
-	return C.cudaFree_wrap(devPtr)
+func CudaMemcpy(dst unsafe.Pointer, src unsafe.Pointer, count uint64, kind int) error {
+	return cudaError("cudaMemcpy", C.int(C.cudaMemcpy_wrap(dst, src, C.size_t(count), C.cudaMemcpyKind(kind))))
 }
 
-func CudaMemcpy(dst unsafe.Pointer, src unsafe.Pointer, count uint64, kind int) C.cudaError_t {
-	return C.cudaMemcpy_wrap(dst, src, C.size_t(count), C.cudaMemcpyKind(kind))
+func CudaDeviceSynchronize() error {
+	return cudaError("cudaDeviceSynchronize", C.int(C.cudaDeviceSynchronize_wrap()))
 }
 
-func CudaDeviceSynchronize() C.cudaError_t {
-	return C.cudaDeviceSynchronize_wrap()
+// CudaGetErrorString returns the description of a cudaError_t.
+func CudaGetErrorString(code int) string {
+	return C.GoString(C.cudaGetErrorString_wrap(C.cudaError_t(code)))
 }
 
-func CudaGetErrorString(err C.cudaError_t) string {
-	return C.GoString(C.cudaGetErrorString_wrap(err))
+// cudaErrorMemoryAllocation is the cudaError_t of a failed allocation.
+const cudaErrorMemoryAllocation = 2
+
+// cudaError returns the error of an operation that returned a cudaError_t, or nil
+// if it succeeded. Failed allocations wrap ErrOutOfMemory.
+func cudaError(op string, code C.int) error {
+	if code == 0 {
+		return nil
+	}
+	if code == cudaErrorMemoryAllocation {
+		return fmt.Errorf("%s: %s (CUDA error %d): %w", op, CudaGetErrorString(int(code)), int(code), ErrOutOfMemory)
+	}
+	return fmt.Errorf("%s: %s (CUDA error %d)", op, CudaGetErrorString(int(code)), int(code))
 }
@@ -1,57 +1,170 @@
 package gpu
 
-// #cgo LDFLAGS: -ldl
-// #include <stdlib.h>
-// #include <dlfcn.h>
-// #include <stdint.h>
-//
-// typedef int (*cuInit_t)(unsigned int);
-// typedef int (*cuDeviceGetCount_t)(int*);
-//
-// // Return 1 if CUDA driver + CUDA runtime + at least one device is present, 0 otherwise.
-// static int detect_cuda() {
-//     void *h = dlopen("libcuda.so", RTLD_LAZY | RTLD_LOCAL);
-//     if (!h) {
-//         // Some systems have versioned library names (e.g. libcuda.so.1).
-//         h = dlopen("libcuda.so.1", RTLD_LAZY | RTLD_LOCAL);
-//         if (!h) {
-//             return 0;
-//         }
-//     }
-//
-//     // We have a hard dependency on the runtime too, so it must be present.
-//     void *rt = dlopen("libcudart.so", RTLD_LAZY | RTLD_LOCAL);
-//     if (!rt) {
-//         return 0;
-//     }
-//
-//     cuInit_t p_cuInit = (cuInit_t)dlsym(h, "cuInit");
-//     cuDeviceGetCount_t p_cuDeviceGetCount = (cuDeviceGetCount_t)dlsym(h, "cuDeviceGetCount");
-//
-//     if (!p_cuInit || !p_cuDeviceGetCount) {
-//         dlclose(h);
-//         return 0;
-//     }
-//
-//     if (p_cuInit(0) != 0) {
-//         dlclose(h);
-//         return 0;
-//     }
-//
-//     int count = 0;
-//     if (p_cuDeviceGetCount(&count) != 0) {
-//         dlclose(h);
-//         return 0;
-//     }
-//
-//     dlclose(h);
-//     return count > 0 ? 1 : 0;
-// }
-import "C"
-
-// HasCUDA returns true if libcuda is present and reports at least one device.
-// This does not require linking to any CUDA library at build time.
+import (
+	"errors"
+	"fmt"
+)
+
+// Driver is the part of the CUDA driver API (libcuda) detection calls.
+type Driver interface {
+	// Init initializes the driver (cuInit).
+	Init() error
+	// Version returns the CUDA version the driver supports, as 1000*major + 10*minor
+	// (cuDriverGetVersion).
+	Version() (int, error)
+	// DeviceCount returns the number of CUDA devices (cuDeviceGetCount).
+	DeviceCount() (int, error)
+	// DeviceName returns the name of a device (cuDeviceGet and cuDeviceGetName).
+	DeviceName(ordinal int) (string, error)
+	Close()
+}
+
+// Runtime is the part of the CUDA runtime API (libcudart) detection calls.
+type Runtime interface {
+	// Version returns the runtime's CUDA version, as 1000*major + 10*minor
+	// (cudaRuntimeGetVersion).
+	Version() (int, error)
+	Close()
+}
+
+// Loader opens the CUDA libraries by name. Detect runs over a Loader, so that
+// tests can give it fake libraries.
+type Loader interface {
+	OpenDriver(name string) (Driver, error)
+	OpenRuntime(name string) (Runtime, error)
+	// OpenKernels opens kernelLib, the kernels built from gpu.cu, for the cuda
+	// device to use.
+	OpenKernels() error
+}
+
+// kernelLib is the library the kernels of gpu.cu are built into (see Makefile).
+const kernelLib = "libgpu.so"
+
+// The library names tried, in order. The runtime names match those the kernels
+// load in cudart_loader.c.
+var (
+	driverLibs  = []string{"libcuda.so", "libcuda.so.1"}
+	runtimeLibs = []string{"libcudart.so", "libcudart.so.13.0", "libcudart.so.12.0", "libcudart.so.11.0", "libcudart.so.10.1"}
+)
+
+// Attempt is a library Detect tried to open, with the error if it failed.
+type Attempt struct {
+	Library string
+	Err     error
+}
+
+// Check is a step of detection, with the error if it failed.
+type Check struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+// Report is what Detect found out about the machine's CUDA setup.
+type Report struct {
+	// Checks are the detection steps, in order.
+	Checks []Check
+	// Tried are the libraries tried, in order.
+	Tried []Attempt
+	// DriverLib and RuntimeLib are the names of the libraries opened, if any.
+	DriverLib, RuntimeLib string
+	// DriverVersion and RuntimeVersion are the CUDA versions, as 1000*major + 10*minor,
+	// or 0 if unknown.
+	DriverVersion, RuntimeVersion int
+	// Devices are the names of the CUDA devices.
+	Devices []string
+	// Available reports whether the GPU can be used: the libraries load, the
+	// driver initializes, and there is at least one device.
+	Available bool
+}
+
+// Detect goes through the steps HasCUDA depends on, recording each one instead
+// of stopping at the first failure, so a machine that runs on the CPU tells why.
+func Detect(l Loader) Report {
+	var r Report
+	check := func(name, detail string, err error) bool {
+		r.Checks = append(r.Checks, Check{Name: name, Detail: detail, Err: err})
+		return err == nil
+	}
+
+	var drv Driver
+	for _, name := range driverLibs {
+		d, err := l.OpenDriver(name)
+		r.Tried = append(r.Tried, Attempt{Library: name, Err: err})
+		if err == nil {
+			drv, r.DriverLib = d, name
+			break
+		}
+	}
+	if check("CUDA driver library", r.DriverLib, notFound(drv != nil, driverLibs)) {
+		defer drv.Close()
+	}
+
+	var rt Runtime
+	for _, name := range runtimeLibs {
+		x, err := l.OpenRuntime(name)
+		r.Tried = append(r.Tried, Attempt{Library: name, Err: err})
+		if err == nil {
+			rt, r.RuntimeLib = x, name
+			break
+		}
+	}
+	if check("CUDA runtime library", r.RuntimeLib, notFound(rt != nil, runtimeLibs)) {
+		defer rt.Close()
+		v, err := rt.Version()
+		r.RuntimeVersion = v
+		check("CUDA runtime version", versionString(v), err)
+	}
+
+	err := l.OpenKernels()
+	r.Tried = append(r.Tried, Attempt{Library: kernelLib, Err: err})
+	kernels := check("Kernel library", kernelLib, err)
+
+	if drv == nil {
+		return r
+	}
+	v, err := drv.Version()
+	r.DriverVersion = v
+	check("CUDA driver version", versionString(v), err)
+	if !check("Driver initialization", "", drv.Init()) {
+		return r
+	}
+	count, err := drv.DeviceCount()
+	if err == nil && count == 0 {
+		err = errors.New("no CUDA devices")
+	}
+	if !check("CUDA devices", fmt.Sprint(count), err) {
+		return r
+	}
+	for i := range count {
+		name, err := drv.DeviceName(i)
+		if err != nil {
+			name = fmt.Sprintf("unknown (%v)", err)
+		}
+		r.Devices = append(r.Devices, name)
+	}
+	r.Available = rt != nil && kernels
+	return r
+}
+
+func notFound(found bool, names []string) error {
+	if found {
+		return nil
+	}
+	return fmt.Errorf("none of %v could be opened", names)
+}
+
+// versionString formats a CUDA version number as major.minor.
+func versionString(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", v/1000, v%1000/10)
+}
+
+// HasCUDA returns true if the CUDA driver and runtime load and report at least
+// one device (see Detect). This does not require linking to any CUDA library at
+// build time.
 func HasCUDA() bool {
-	ret := C.detect_cuda()
-	return ret == 1
+	return Detect(SystemLoader()).Available
 }
@@ -0,0 +1,38 @@
+package gpu
+
+import (
+	"errors"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// Device runs the tick kernel on a grid of cell states it keeps between calls.
+// Cells are row-major; 0 is dead, 1 alive and 2..states-1 the dying states of
+// Generations rules. Cells past the edges of an unbounded or plane grid are dead,
+// and a bounded grid's neighbors wrap around its edges.
+type Device interface {
+	// Load replaces the grid with rows x cols cells, stepped from then on with
+	// the transition table of a rule (see rule.Rule.Table).
+	Load(cells []int32, rows, cols int, table [512]uint8, states int, t board.Topology) error
+	// Step advances the grid by n generations.
+	Step(n int) error
+	// BorderAlive reports whether a non-dead cell lies within width cells of the
+	// edges of the grid.
+	BorderAlive(width int) (bool, error)
+	// Read copies the grid into cells.
+	Read(cells []int32) error
+	// StepTiles advances count tiles of size x size cells by one generation,
+	// independently of the loaded grid. in holds each tile with a one-cell halo of
+	// its neighbors, (size+2)x(size+2) cells, and out receives the size x size
+	// cells of each tile's next generation.
+	StepTiles(in, out []int32, count, size int, table [512]uint8, states int) error
+	// Close frees the grid.
+	Close()
+}
+
+// ErrOutOfMemory is returned, wrapped, when a grid or batch of tiles doesn't fit
+// in device memory.
+var ErrOutOfMemory = errors.New("out of device memory")
+
+// errNoGrid is returned by the calls that need a grid before one is loaded.
+var errNoGrid = errors.New("no grid loaded")
@@ -0,0 +1,145 @@
+//go:build cgo && !nocuda && !fakecuda
+
+package gpu
+
+// #cgo LDFLAGS: -ldl
+// #include <stdlib.h>
+// #include <dlfcn.h>
+//
+// static void *open_lib(const char *name) {
+//     dlerror();
+//     return dlopen(name, RTLD_LAZY | RTLD_LOCAL);
+// }
+//
+// static const char *last_error(void) {
+//     const char *err = dlerror();
+//     return err ? err : "unknown error";
+// }
+//
+// static int call_uint(void *f, unsigned int arg) {
+//     return ((int (*)(unsigned int))f)(arg);
+// }
+//
+// static int call_out(void *f, int *out) {
+//     return ((int (*)(int *))f)(out);
+// }
+//
+// static int call_device_get(void *f, int *dev, int ordinal) {
+//     return ((int (*)(int *, int))f)(dev, ordinal);
+// }
+//
+// static int call_device_get_name(void *f, char *name, int len, int dev) {
+//     return ((int (*)(char *, int, int))f)(name, len, dev);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// dlLoader opens the CUDA libraries with dlopen.
+type dlLoader struct{}
+
+// SystemLoader returns the Loader of the libraries installed on the machine.
+func SystemLoader() Loader {
+	return dlLoader{}
+}
+
+// library is a shared library opened with dlopen, with the symbols looked up in it.
+type library struct {
+	h    unsafe.Pointer
+	syms map[string]unsafe.Pointer
+}
+
+func openLibrary(name string, symbols ...string) (*library, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	h := C.open_lib(cname)
+	if h == nil {
+		return nil, errors.New(C.GoString(C.last_error()))
+	}
+	l := &library{h: h, syms: make(map[string]unsafe.Pointer)}
+	for _, sym := range symbols {
+		csym := C.CString(sym)
+		p := C.dlsym(h, csym)
+		C.free(unsafe.Pointer(csym))
+		if p == nil {
+			l.Close()
+			return nil, fmt.Errorf("%s: missing symbol %s", name, sym)
+		}
+		l.syms[sym] = p
+	}
+	return l, nil
+}
+
+func (l *library) Close() {
+	C.dlclose(l.h)
+}
+
+// driverError returns the error of a CUresult.
+func driverError(op string, code C.int) error {
+	if code == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: CUDA driver error %d", op, int(code))
+}
+
+type dlDriver struct{ *library }
+
+func (dlLoader) OpenDriver(name string) (Driver, error) {
+	l, err := openLibrary(name, "cuInit", "cuDriverGetVersion", "cuDeviceGetCount", "cuDeviceGet", "cuDeviceGetName")
+	if err != nil {
+		return nil, err
+	}
+	return dlDriver{l}, nil
+}
+
+func (d dlDriver) Init() error {
+	return driverError("cuInit", C.call_uint(d.syms["cuInit"], 0))
+}
+
+func (d dlDriver) Version() (int, error) {
+	var v C.int
+	err := driverError("cuDriverGetVersion", C.call_out(d.syms["cuDriverGetVersion"], &v))
+	return int(v), err
+}
+
+func (d dlDriver) DeviceCount() (int, error) {
+	var n C.int
+	err := driverError("cuDeviceGetCount", C.call_out(d.syms["cuDeviceGetCount"], &n))
+	return int(n), err
+}
+
+func (d dlDriver) DeviceName(ordinal int) (string, error) {
+	var dev C.int
+	if err := driverError("cuDeviceGet", C.call_device_get(d.syms["cuDeviceGet"], &dev, C.int(ordinal))); err != nil {
+		return "", err
+	}
+	var name [256]C.char
+	if err := driverError("cuDeviceGetName", C.call_device_get_name(d.syms["cuDeviceGetName"], &name[0], C.int(len(name)), dev)); err != nil {
+		return "", err
+	}
+	return C.GoString(&name[0]), nil
+}
+
+// OpenKernels opens libgpu.so for the cuda device, once.
+func (dlLoader) OpenKernels() error {
+	return loadKernels()
+}
+
+type dlRuntime struct{ *library }
+
+func (dlLoader) OpenRuntime(name string) (Runtime, error) {
+	l, err := openLibrary(name, "cudaRuntimeGetVersion")
+	if err != nil {
+		return nil, err
+	}
+	return dlRuntime{l}, nil
+}
+
+func (r dlRuntime) Version() (int, error) {
+	var v C.int
+	err := cudaError("cudaRuntimeGetVersion", C.call_out(r.syms["cudaRuntimeGetVersion"], &v))
+	return int(v), err
+}
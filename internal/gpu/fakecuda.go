@@ -0,0 +1,83 @@
+//go:build cgo && !nocuda && fakecuda
+
+package gpu
+
+/*
+#cgo CFLAGS: -I.
+#include "fakecuda.h"
+*/
+import "C"
+
+// With the fakecuda build tag, the CUDA runtime and the kernels of gpu.cu are
+// replaced by a CPU stand-in over host memory (fakecuda_runtime.c and
+// fakecuda_gpu.c), so the cgo side of the package, from the Go wrappers down to
+// the host drivers' error handling, runs under go test on any machine:
+//
+//	go test -tags fakecuda ./...
+//
+// The functions below inject failures into the stand-in.
+
+// FakeCall is a CUDA runtime call a failure can be injected into.
+type FakeCall int
+
+const (
+	FakeMalloc FakeCall = C.FAKE_CUDA_MALLOC
+	FakeMemcpy FakeCall = C.FAKE_CUDA_MEMCPY
+	// FakeSync fails kernels, whose errors are reported by cudaDeviceSynchronize.
+	FakeSync FakeCall = C.FAKE_CUDA_SYNC
+)
+
+// loadKernels succeeds: the stand-in for the kernels is compiled in.
+func loadKernels() error {
+	return nil
+}
+
+// fakeLoader opens the stand-in under any library name, so HasCUDA is true.
+type fakeLoader struct{}
+
+// SystemLoader returns the Loader of the stand-in, which reports one device.
+func SystemLoader() Loader {
+	return fakeLoader{}
+}
+
+func (fakeLoader) OpenDriver(name string) (Driver, error) {
+	return fakeDriver{}, nil
+}
+
+func (fakeLoader) OpenRuntime(name string) (Runtime, error) {
+	return fakeDriver{}, nil
+}
+
+func (fakeLoader) OpenKernels() error {
+	return nil
+}
+
+// fakeDriver is the driver and runtime of the stand-in.
+type fakeDriver struct{}
+
+func (fakeDriver) Init() error                            { return nil }
+func (fakeDriver) Version() (int, error)                  { return 13000, nil }
+func (fakeDriver) DeviceCount() (int, error)              { return 1, nil }
+func (fakeDriver) DeviceName(ordinal int) (string, error) { return "CPU stand-in (fakecuda)", nil }
+func (fakeDriver) Close()                                 {}
+
+// FakeFail makes every later call of a kind return a cudaError_t, until FakeReset.
+func FakeFail(call FakeCall, code int) {
+	C.fake_cuda_fail(C.int(call), C.int(code))
+}
+
+// FakeLimit makes allocations past a total of bytes fail as out of memory, until
+// FakeReset.
+func FakeLimit(bytes int) {
+	C.fake_cuda_limit(C.size_t(bytes))
+}
+
+// FakeReset clears injected failures and the memory limit.
+func FakeReset() {
+	C.fake_cuda_reset()
+}
+
+// FakeInUse returns the number of bytes of device memory allocated and not freed.
+func FakeInUse() int {
+	return int(C.fake_cuda_in_use())
+}
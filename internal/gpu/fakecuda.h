@@ -0,0 +1,28 @@
+// fakecuda.h: controls of the CPU stand-in for the CUDA runtime and kernels,
+// compiled in place of cudart_loader.c and libgpu.so by the fakecuda build tag.
+#ifndef FAKECUDA_H
+#define FAKECUDA_H
+
+#include <stddef.h>
+
+#define cudaSuccess 0
+#define cudaErrorMemoryAllocation 2
+#define cudaMemcpyHostToDevice 1
+#define cudaMemcpyDeviceToHost 2
+
+// Runtime calls a failure can be injected into
+#define FAKE_CUDA_MALLOC 0
+#define FAKE_CUDA_MEMCPY 1
+#define FAKE_CUDA_SYNC 2
+
+// fake_cuda_fail makes every later call of a kind return code, until reset.
+void fake_cuda_fail(int call, int code);
+// fake_cuda_limit makes allocations past a total of bytes fail with
+// cudaErrorMemoryAllocation; 0 means no limit.
+void fake_cuda_limit(size_t bytes);
+// fake_cuda_reset clears failures and the limit.
+void fake_cuda_reset(void);
+// fake_cuda_in_use returns the number of bytes allocated and not freed.
+size_t fake_cuda_in_use(void);
+
+#endif // FAKECUDA_H
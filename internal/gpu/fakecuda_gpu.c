@@ -0,0 +1,231 @@
+//go:build cgo && !nocuda && fakecuda
+
+// fakecuda_gpu.c: the host drivers of gpu.cu with its kernels run on the CPU, one
+// thread index at a time, over the memory of the fake runtime.
+#include <stdlib.h>
+#include "cudart_loader.h"
+#include "fakecuda.h"
+#include "gpu.h"
+
+// Topology kinds, matching board.TopologyKind
+#define TOPOLOGY_PLANE 1
+#define TOPOLOGY_TORUS 2
+#define TOPOLOGY_KLEIN 3
+#define TOPOLOGY_CROSS 4
+
+static int floor_div(int a, int b)
+{
+  int q = a / b;
+  if (a % b != 0 && ((a < 0) != (b < 0)))
+    --q;
+  return q;
+}
+
+// Mirrors `wrap` in gpu.cu
+static int wrap(int *r, int *c, int rows, int cols, struct topology topo)
+{
+  if (topo.kind <= TOPOLOGY_PLANE)
+    return *r >= 0 && *r < rows && *c >= 0 && *c < cols;
+
+  int cross_rows = floor_div(*r, rows);
+  int cross_cols = floor_div(*c, cols);
+  if (topo.kind == TOPOLOGY_TORUS)
+  {
+    *c += cross_rows * topo.shift_col;
+    *r += cross_cols * topo.shift_row;
+    cross_rows = floor_div(*r, rows);
+    cross_cols = floor_div(*c, cols);
+  }
+  *r -= cross_rows * rows;
+  *c -= cross_cols * cols;
+
+  int mirror_cols = topo.kind == TOPOLOGY_CROSS || (topo.kind == TOPOLOGY_KLEIN && !topo.twist_rows);
+  int mirror_rows = topo.kind == TOPOLOGY_CROSS || (topo.kind == TOPOLOGY_KLEIN && topo.twist_rows);
+  if (mirror_cols && (cross_rows & 1))
+    *c = cols - 1 - *c;
+  if (mirror_rows && (cross_cols & 1))
+    *r = rows - 1 - *r;
+  return 1;
+}
+
+// Mirrors `next_state` in gpu.cu
+static int next_state(int cur, int config, const unsigned char *table, int states)
+{
+  if (cur == 0)
+    return table[config];
+  if (cur == 1 && table[256 + config])
+    return 1;
+  return (cur + 1 < states) ? cur + 1 : 0;
+}
+
+// Mirrors `tick_cuda` in gpu.cu for thread idx
+static void tick_cuda(int idx, const int *src, int *dst, int rows, int cols, const unsigned char *table, int states, struct topology topo)
+{
+  int r = idx / cols;
+  int c = idx % cols;
+  int config = 0;
+  int bit = 0;
+  for (int dr = -1; dr <= 1; ++dr)
+  {
+    for (int dc = -1; dc <= 1; ++dc)
+    {
+      if (dr == 0 && dc == 0)
+        continue;
+      int rr = r + dr;
+      int cc = c + dc;
+      if (wrap(&rr, &cc, rows, cols, topo) && src[rr * cols + cc] == 1)
+        config |= 1 << bit;
+      ++bit;
+    }
+  }
+  dst[idx] = next_state(src[idx], config, table, states);
+}
+
+// Mirrors `tick_tiles_cuda` in gpu.cu for thread idx
+static void tick_tiles_cuda(long long idx, const int *in, int *out, int size, const unsigned char *table, int states)
+{
+  long long cells = (long long)size * size;
+  int tile = (int)(idx / cells);
+  int r = (int)(idx % cells) / size;
+  int c = (int)(idx % cells) % size;
+  int stride = size + 2;
+  const int *t = in + (long long)tile * stride * stride;
+
+  int config = 0;
+  int bit = 0;
+  for (int dr = -1; dr <= 1; ++dr)
+  {
+    for (int dc = -1; dc <= 1; ++dc)
+    {
+      if (dr == 0 && dc == 0)
+        continue;
+      if (t[(r + 1 + dr) * stride + (c + 1 + dc)] == 1)
+        config |= 1 << bit;
+      ++bit;
+    }
+  }
+  out[idx] = next_state(t[(r + 1) * stride + (c + 1)], config, table, states);
+}
+
+void square(float *a, int N)
+{
+  float *a_d;
+  size_t size = N * sizeof(float);
+  if (cudaMalloc_wrap((void **)&a_d, size) != cudaSuccess)
+    return;
+  cudaMemcpy_wrap(a_d, a, size, cudaMemcpyHostToDevice);
+  for (int idx = 0; idx < N; ++idx)
+    a_d[idx] = a_d[idx] * a_d[idx];
+  cudaMemcpy_wrap(a, a_d, size, cudaMemcpyDeviceToHost);
+  cudaFree_wrap(a_d);
+}
+
+struct session
+{
+  int *cur, *next;
+  unsigned char *table;
+  int *flag;
+  int rows, cols, states;
+  struct topology topo;
+};
+
+void session_free(struct session *s)
+{
+  if (!s)
+    return;
+  cudaFree_wrap(s->cur);
+  cudaFree_wrap(s->next);
+  cudaFree_wrap(s->table);
+  cudaFree_wrap(s->flag);
+  free(s);
+}
+
+int session_new(struct session **out, const int *cells, int rows, int cols, const unsigned char *table, int states, struct topology topo)
+{
+  struct session *s = (struct session *)calloc(1, sizeof(struct session));
+  if (!s)
+    return cudaErrorMemoryAllocation;
+  s->rows = rows;
+  s->cols = cols;
+  s->states = states;
+  s->topo = topo;
+
+  size_t bytes = (size_t)rows * (size_t)cols * sizeof(int);
+  cudaError_t err;
+  if ((err = cudaMalloc_wrap((void **)&s->cur, bytes)) != cudaSuccess ||
+      (err = cudaMalloc_wrap((void **)&s->next, bytes)) != cudaSuccess ||
+      (err = cudaMalloc_wrap((void **)&s->table, 512)) != cudaSuccess ||
+      (err = cudaMalloc_wrap((void **)&s->flag, sizeof(int))) != cudaSuccess ||
+      (err = cudaMemcpy_wrap(s->cur, cells, bytes, cudaMemcpyHostToDevice)) != cudaSuccess ||
+      (err = cudaMemcpy_wrap(s->table, table, 512, cudaMemcpyHostToDevice)) != cudaSuccess)
+  {
+    session_free(s);
+    return err;
+  }
+  *out = s;
+  return cudaSuccess;
+}
+
+int session_step(struct session *s, int n)
+{
+  int total = s->rows * s->cols;
+  for (int i = 0; i < n; ++i)
+  {
+    for (int idx = 0; idx < total; ++idx)
+      tick_cuda(idx, s->cur, s->next, s->rows, s->cols, s->table, s->states, s->topo);
+    int *t = s->cur;
+    s->cur = s->next;
+    s->next = t;
+  }
+  return cudaDeviceSynchronize_wrap();
+}
+
+int session_border_alive(struct session *s, int width, int *alive)
+{
+  int zero = 0;
+  cudaError_t err = cudaMemcpy_wrap(s->flag, &zero, sizeof(int), cudaMemcpyHostToDevice);
+  if (err != cudaSuccess)
+    return err;
+  for (int idx = 0; idx < s->rows * s->cols; ++idx)
+  {
+    int r = idx / s->cols;
+    int c = idx % s->cols;
+    if ((r < width || r >= s->rows - width || c < width || c >= s->cols - width) && s->cur[idx] != 0)
+      *s->flag = 1;
+  }
+  return cudaMemcpy_wrap(alive, s->flag, sizeof(int), cudaMemcpyDeviceToHost);
+}
+
+int session_read(struct session *s, int *cells)
+{
+  size_t bytes = (size_t)s->rows * (size_t)s->cols * sizeof(int);
+  return cudaMemcpy_wrap(cells, s->cur, bytes, cudaMemcpyDeviceToHost);
+}
+
+int tick_tiles(const int *in, int *out, int count, int size, const unsigned char *table, int states)
+{
+  size_t in_bytes = (size_t)count * (size + 2) * (size + 2) * sizeof(int);
+  size_t out_bytes = (size_t)count * size * size * sizeof(int);
+  int *in_d = NULL;
+  int *out_d = NULL;
+  unsigned char *table_d = NULL;
+
+  cudaError_t err;
+  if ((err = cudaMalloc_wrap((void **)&in_d, in_bytes)) == cudaSuccess &&
+      (err = cudaMalloc_wrap((void **)&out_d, out_bytes)) == cudaSuccess &&
+      (err = cudaMalloc_wrap((void **)&table_d, 512)) == cudaSuccess &&
+      (err = cudaMemcpy_wrap(in_d, in, in_bytes, cudaMemcpyHostToDevice)) == cudaSuccess &&
+      (err = cudaMemcpy_wrap(table_d, table, 512, cudaMemcpyHostToDevice)) == cudaSuccess)
+  {
+    long long total = (long long)count * size * size;
+    for (long long idx = 0; idx < total; ++idx)
+      tick_tiles_cuda(idx, in_d, out_d, size, table_d, states);
+    if ((err = cudaDeviceSynchronize_wrap()) == cudaSuccess)
+      err = cudaMemcpy_wrap(out, out_d, out_bytes, cudaMemcpyDeviceToHost);
+  }
+
+  cudaFree_wrap(in_d);
+  cudaFree_wrap(out_d);
+  cudaFree_wrap(table_d);
+  return err;
+}
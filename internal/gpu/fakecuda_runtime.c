@@ -0,0 +1,109 @@
+//go:build cgo && !nocuda && fakecuda
+
+// fakecuda_runtime.c: the functions of cudart_loader.h over host memory, so the
+// gpu package can run without a GPU or the CUDA runtime.
+#include <stdlib.h>
+#include "cudart_loader.h"
+#include "fakecuda.h"
+
+// Sessions dropped without Close are freed from the goroutine running cleanups,
+// so the counters are updated atomically, as any thread may call the runtime.
+static int fail_codes[3];
+static size_t limit;
+static size_t in_use;
+
+// Allocations are prefixed with their size, so cudaFree knows what it gives back
+typedef union
+{
+  size_t size;
+  max_align_t align;
+} header;
+
+void fake_cuda_fail(int call, int code)
+{
+  fail_codes[call] = code;
+}
+
+void fake_cuda_limit(size_t bytes)
+{
+  limit = bytes;
+}
+
+void fake_cuda_reset(void)
+{
+  for (int i = 0; i < 3; ++i)
+    fail_codes[i] = cudaSuccess;
+  limit = 0;
+}
+
+size_t fake_cuda_in_use(void)
+{
+  return __atomic_load_n(&in_use, __ATOMIC_SEQ_CST);
+}
+
+int loadCUDARuntime(void)
+{
+  return 1;
+}
+
+void unloadCUDARuntime(void)
+{
+}
+
+cudaError_t cudaMalloc_wrap(void **devPtr, size_t size)
+{
+  if (fail_codes[FAKE_CUDA_MALLOC])
+    return fail_codes[FAKE_CUDA_MALLOC];
+  if (limit && fake_cuda_in_use() + size > limit)
+    return cudaErrorMemoryAllocation;
+  header *h = (header *)malloc(sizeof(header) + size);
+  if (!h)
+    return cudaErrorMemoryAllocation;
+  h->size = size;
+  __atomic_add_fetch(&in_use, size, __ATOMIC_SEQ_CST);
+  *devPtr = h + 1;
+  return cudaSuccess;
+}
+
+cudaError_t cudaFree_wrap(void *devPtr)
+{
+  if (!devPtr)
+    return cudaSuccess;
+  header *h = (header *)devPtr - 1;
+  __atomic_sub_fetch(&in_use, h->size, __ATOMIC_SEQ_CST);
+  free(h);
+  return cudaSuccess;
+}
+
+cudaError_t cudaMemcpy_wrap(void *dst, const void *src, size_t count, cudaMemcpyKind kind)
+{
+  (void)kind;
+  if (fail_codes[FAKE_CUDA_MEMCPY])
+    return fail_codes[FAKE_CUDA_MEMCPY];
+  char *d = (char *)dst;
+  const char *s = (const char *)src;
+  for (size_t i = 0; i < count; ++i)
+    d[i] = s[i];
+  return cudaSuccess;
+}
+
+cudaError_t cudaDeviceSynchronize_wrap(void)
+{
+  return fail_codes[FAKE_CUDA_SYNC];
+}
+
+const char *cudaGetErrorString_wrap(cudaError_t error)
+{
+  switch (error)
+  {
+  case cudaSuccess:
+    return "no error";
+  case cudaErrorMemoryAllocation:
+    return "out of memory";
+  case 700:
+    return "an illegal memory access was encountered";
+  case 719:
+    return "unspecified launch failure";
+  }
+  return "unknown error";
+}
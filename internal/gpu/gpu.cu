@@ -1,7 +1,12 @@
-#include <stdio.h>
+#include <stdlib.h>
 #include <cuda.h>
 #include "cudart_loader.h"
 
+extern "C"
+{
+#include "gpu.h"
+}
+
 // Device (kernel)
 __global__ void square_cuda(float *a, int N)
 {
@@ -10,8 +15,71 @@ __global__ void square_cuda(float *a, int N)
     a[idx] = a[idx] * a[idx];
 }
 
-// Device `tick` (kernel). src and dst are flat row-major int arrays (0 or 1).
-__global__ void tick_cuda(const int *src, int *dst, int rows, int cols)
+// Topology kinds, matching board.TopologyKind
+#define TOPOLOGY_PLANE 1
+#define TOPOLOGY_TORUS 2
+#define TOPOLOGY_KLEIN 3
+#define TOPOLOGY_CROSS 4
+
+__device__ int floor_div(int a, int b)
+{
+  int q = a / b;
+  if (a % b != 0 && ((a < 0) != (b < 0)))
+    --q;
+  return q;
+}
+
+// Device helper: maps a position past the edges of the grid to the cell it refers to,
+// like board.Topology.Wrap. Returns 0 if the position is off the grid (always dead).
+__device__ int wrap(int *r, int *c, int rows, int cols, struct topology topo)
+{
+  if (topo.kind <= TOPOLOGY_PLANE)
+    return *r >= 0 && *r < rows && *c >= 0 && *c < cols;
+
+  int cross_rows = floor_div(*r, rows);
+  int cross_cols = floor_div(*c, cols);
+  if (topo.kind == TOPOLOGY_TORUS)
+  {
+    *c += cross_rows * topo.shift_col;
+    *r += cross_cols * topo.shift_row;
+    cross_rows = floor_div(*r, rows);
+    cross_cols = floor_div(*c, cols);
+  }
+  *r -= cross_rows * rows;
+  *c -= cross_cols * cols;
+
+  int mirror_cols = topo.kind == TOPOLOGY_CROSS || (topo.kind == TOPOLOGY_KLEIN && !topo.twist_rows);
+  int mirror_rows = topo.kind == TOPOLOGY_CROSS || (topo.kind == TOPOLOGY_KLEIN && topo.twist_rows);
+  if (mirror_cols && (cross_rows & 1))
+    *c = cols - 1 - *c;
+  if (mirror_rows && (cross_cols & 1))
+    *r = rows - 1 - *r;
+  return 1;
+}
+
+// Device helper: the next state of a cell in state cur with the given neighborhood configuration.
+__device__ int next_state(int cur, int config, const unsigned char *table, int states)
+{
+  if (cur == 0)
+  {
+    // dead cell: becomes alive if its neighborhood is a birth configuration
+    return table[config];
+  }
+  if (cur == 1 && table[256 + config])
+  {
+    // live cell: survives if its neighborhood is a survival configuration
+    return 1;
+  }
+  // live cell that failed to survive, or dying cell: age by one state
+  return (cur + 1 < states) ? cur + 1 : 0;
+}
+
+// Device `tick` (kernel). src and dst are flat row-major int arrays of cell states,
+// 0 (dead), 1 (alive) or 2..states-1 (dying, for Generations rules).
+// table holds the birth (0-255) and survival (256-511) transitions indexed by the
+// neighborhood configuration, whose bit i is set when the i-th neighbor in row-major order is alive.
+// On a bounded grid, src and dst cover the whole grid and neighbors wrap around its edges.
+__global__ void tick_cuda(const int *src, int *dst, int rows, int cols, const unsigned char *table, int states, struct topology topo)
 {
   int idx = blockIdx.x * blockDim.x + threadIdx.x;
   int total = rows * cols;
@@ -21,7 +89,8 @@ __global__ void tick_cuda(const int *src, int *dst, int rows, int cols)
   int r = idx / cols;
   int c = idx % cols;
 
-  int alive = 0;
+  int config = 0;
+  int bit = 0;
 
   // iterate neighbors
   for (int dr = -1; dr <= 1; ++dr)
@@ -33,27 +102,59 @@ __global__ void tick_cuda(const int *src, int *dst, int rows, int cols)
       int rr = r + dr;
       int cc = c + dc;
 
-      // bounds check: treat outside as dead
-      if (rr < 0 || rr >= rows || cc < 0 || cc >= cols)
-        continue;
-      int nidx = rr * cols + cc;
-      alive += src[nidx];
+      // bounds check: wrap or treat outside as dead
+      if (wrap(&rr, &cc, rows, cols, topo) && src[rr * cols + cc] == 1)
+        config |= 1 << bit;
+      ++bit;
     }
   }
 
-  int cur = src[idx];
-  int next = 0;
-  if (cur == 1)
-  {
-    // live cell: survives if 2 or 3 neighbors
-    next = (alive == 2 || alive == 3) ? 1 : 0;
-  }
-  else
+  dst[idx] = next_state(src[idx], config, table, states);
+}
+
+// Device `tick_tiles` (kernel). in holds count tiles of (size+2)x(size+2) cells,
+// each a size x size tile with a one-cell halo of its neighbors; out receives the
+// next generation of the size x size inner cells of each tile.
+__global__ void tick_tiles_cuda(const int *in, int *out, int count, int size, const unsigned char *table, int states)
+{
+  long long idx = (long long)blockIdx.x * blockDim.x + threadIdx.x;
+  long long cells = (long long)size * size;
+  if (idx >= cells * count)
+    return;
+
+  int tile = (int)(idx / cells);
+  int r = (int)(idx % cells) / size;
+  int c = (int)(idx % cells) % size;
+  int stride = size + 2;
+  const int *t = in + (long long)tile * stride * stride;
+
+  int config = 0;
+  int bit = 0;
+  for (int dr = -1; dr <= 1; ++dr)
   {
-    // dead cell: becomes alive if exactly 3 neighbors
-    next = (alive == 3) ? 1 : 0;
+    for (int dc = -1; dc <= 1; ++dc)
+    {
+      if (dr == 0 && dc == 0)
+        continue;
+      if (t[(r + 1 + dr) * stride + (c + 1 + dc)] == 1)
+        config |= 1 << bit;
+      ++bit;
+    }
   }
-  dst[idx] = next;
+  out[idx] = next_state(t[(r + 1) * stride + (c + 1)], config, table, states);
+}
+
+// Device `border_alive` (kernel): sets *flag if a non-dead cell lies within width
+// cells of the edges of the grid.
+__global__ void border_alive_cuda(const int *grid, int rows, int cols, int width, int *flag)
+{
+  int idx = blockIdx.x * blockDim.x + threadIdx.x;
+  if (idx >= rows * cols)
+    return;
+  int r = idx / cols;
+  int c = idx % cols;
+  if ((r < width || r >= rows - width || c < width || c >= cols - width) && grid[idx] != 0)
+    *flag = 1;
 }
 
 extern "C"
@@ -82,59 +183,125 @@ extern "C"
     cudaFree_wrap(a_d);
   }
 
-  // Host driver - implements gpu.h `tick`
-  void tick(int *src, int *dst, int rows, int cols)
+  struct session
+  {
+    int *cur, *next; // device grids, swapped after every generation
+    unsigned char *table;
+    int *flag;
+    int rows, cols, states;
+    struct topology topo;
+  };
+
+  // Host driver - implements gpu.h `session_free`
+  void session_free(struct session *s)
   {
-    size_t n = (size_t)rows * (size_t)cols;
-    if (n == 0)
+    if (!s)
       return;
+    cudaFree_wrap(s->cur);
+    cudaFree_wrap(s->next);
+    cudaFree_wrap(s->table);
+    cudaFree_wrap(s->flag);
+    free(s);
+  }
 
-    int *src_d = nullptr;
-    int *dst_d = nullptr;
-    size_t bytes = n * sizeof(int);
+  // Host driver - implements gpu.h `session_new`
+  int session_new(struct session **out, const int *cells, int rows, int cols, const unsigned char *table, int states, struct topology topo)
+  {
+    struct session *s = (struct session *)calloc(1, sizeof(struct session));
+    if (!s)
+      return cudaErrorMemoryAllocation;
+    s->rows = rows;
+    s->cols = cols;
+    s->states = states;
+    s->topo = topo;
 
+    size_t bytes = (size_t)rows * (size_t)cols * sizeof(int);
+    size_t table_bytes = 512;
     cudaError_t err;
-
-    // Allocate memory on the device for src & dst
-    err = cudaMalloc_wrap((void **)&src_d, bytes);
-    if (err != cudaSuccess)
+    if ((err = cudaMalloc_wrap((void **)&s->cur, bytes)) != cudaSuccess ||
+        (err = cudaMalloc_wrap((void **)&s->next, bytes)) != cudaSuccess ||
+        (err = cudaMalloc_wrap((void **)&s->table, table_bytes)) != cudaSuccess ||
+        (err = cudaMalloc_wrap((void **)&s->flag, sizeof(int))) != cudaSuccess)
     {
-      fprintf(stderr, "cudaMalloc src failed: %s\n", cudaGetErrorString_wrap(err));
-      return;
+      session_free(s);
+      return err;
     }
-    err = cudaMalloc_wrap((void **)&dst_d, bytes);
-    if (err != cudaSuccess)
+    if ((err = cudaMemcpy_wrap(s->cur, cells, bytes, cudaMemcpyHostToDevice)) != cudaSuccess ||
+        (err = cudaMemcpy_wrap(s->table, table, table_bytes, cudaMemcpyHostToDevice)) != cudaSuccess)
     {
-      fprintf(stderr, "cudaMalloc dst failed: %s\n", cudaGetErrorString_wrap(err));
-      cudaFree_wrap(src_d);
-      return;
+      session_free(s);
+      return err;
     }
+    *out = s;
+    return cudaSuccess;
+  }
 
-    // Copy values to the device for src
-    err = cudaMemcpy_wrap(src_d, src, bytes, cudaMemcpyHostToDevice);
-    if (err != cudaSuccess)
+  // Host driver - implements gpu.h `session_step`
+  int session_step(struct session *s, int n)
+  {
+    size_t total = (size_t)s->rows * (size_t)s->cols;
+    int block_size = 256;
+    int n_blocks = (int)((total + block_size - 1) / block_size);
+    for (int i = 0; i < n; ++i)
     {
-      fprintf(stderr, "cudaMemcpy to device failed: %s\n", cudaGetErrorString_wrap(err));
-      cudaFree_wrap(src_d);
-      cudaFree_wrap(dst_d);
-      return;
+      tick_cuda<<<n_blocks, block_size>>>(s->cur, s->next, s->rows, s->cols, s->table, s->states, s->topo);
+      int *t = s->cur;
+      s->cur = s->next;
+      s->next = t;
     }
+    return cudaDeviceSynchronize_wrap();
+  }
 
-    // Launch the kernel to do the thing
+  // Host driver - implements gpu.h `session_border_alive`
+  int session_border_alive(struct session *s, int width, int *alive)
+  {
+    size_t total = (size_t)s->rows * (size_t)s->cols;
     int block_size = 256;
-    int n_blocks = (int)((n + block_size - 1) / block_size);
+    int n_blocks = (int)((total + block_size - 1) / block_size);
+    int zero = 0;
+    cudaError_t err = cudaMemcpy_wrap(s->flag, &zero, sizeof(int), cudaMemcpyHostToDevice);
+    if (err != cudaSuccess)
+      return err;
+    border_alive_cuda<<<n_blocks, block_size>>>(s->cur, s->rows, s->cols, width, s->flag);
+    return cudaMemcpy_wrap(alive, s->flag, sizeof(int), cudaMemcpyDeviceToHost);
+  }
 
-    tick_cuda<<<n_blocks, block_size>>>(src_d, dst_d, rows, cols);
-    cudaDeviceSynchronize_wrap();
+  // Host driver - implements gpu.h `tick_tiles`
+  int tick_tiles(const int *in, int *out, int count, int size, const unsigned char *table, int states)
+  {
+    size_t in_bytes = (size_t)count * (size + 2) * (size + 2) * sizeof(int);
+    size_t out_bytes = (size_t)count * size * size * sizeof(int);
+    int *in_d = nullptr;
+    int *out_d = nullptr;
+    unsigned char *table_d = nullptr;
 
-    // Copy the device dst to our host
-    err = cudaMemcpy_wrap(dst, dst_d, bytes, cudaMemcpyDeviceToHost);
-    if (err != cudaSuccess)
+    // Errors go back to the caller, which retries with fewer tiles when running
+    // out of memory
+    cudaError_t err;
+    if ((err = cudaMalloc_wrap((void **)&in_d, in_bytes)) == cudaSuccess &&
+        (err = cudaMalloc_wrap((void **)&out_d, out_bytes)) == cudaSuccess &&
+        (err = cudaMalloc_wrap((void **)&table_d, 512)) == cudaSuccess &&
+        (err = cudaMemcpy_wrap(in_d, in, in_bytes, cudaMemcpyHostToDevice)) == cudaSuccess &&
+        (err = cudaMemcpy_wrap(table_d, table, 512, cudaMemcpyHostToDevice)) == cudaSuccess)
     {
-      fprintf(stderr, "cudaMemcpy to host failed: %s\n", cudaGetErrorString_wrap(err));
+      size_t total = (size_t)count * size * size;
+      int block_size = 256;
+      int n_blocks = (int)((total + block_size - 1) / block_size);
+      tick_tiles_cuda<<<n_blocks, block_size>>>(in_d, out_d, count, size, table_d, states);
+      if ((err = cudaDeviceSynchronize_wrap()) == cudaSuccess)
+        err = cudaMemcpy_wrap(out, out_d, out_bytes, cudaMemcpyDeviceToHost);
     }
 
-    cudaFree_wrap(src_d);
-    cudaFree_wrap(dst_d);
+    cudaFree_wrap(in_d);
+    cudaFree_wrap(out_d);
+    cudaFree_wrap(table_d);
+    return err;
+  }
+
+  // Host driver - implements gpu.h `session_read`
+  int session_read(struct session *s, int *cells)
+  {
+    size_t bytes = (size_t)s->rows * (size_t)s->cols * sizeof(int);
+    return cudaMemcpy_wrap(cells, s->cur, bytes, cudaMemcpyDeviceToHost);
   }
 }
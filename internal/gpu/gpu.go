@@ -1,81 +1,36 @@
 package gpu
 
-/*
-#cgo CFLAGS: -I.
-#cgo LDFLAGS: -L. -lgpu
-#include "gpu.h"
-*/
-import "C"
 import (
-	"unsafe"
-
 	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
 )
 
-// Initial demo only to get gpu stuff hooked up, not used
-func Square(a []float32) {
-	C.square((*C.float)(&a[0]), C.int(len(a)))
+// Supports reports whether the GPU kernel can run a rule. The kernel only evaluates
+// the 8-cell Moore neighborhood (which also covers hexagonal rules), so Larger than
+// Life and triangular rules run on the CPU.
+func Supports(r *rule.Rule) bool {
+	return r.LtL == nil && !r.Neighborhood.IsTriangular()
 }
 
 // Our Game of Life rules applied
-func Tick(src, dst *board.InfiniteGrid) {
-	sminR, sminC, smaxR, smaxC := src.Bounds()
-	if len(src.Cells) == 0 {
-		dst.Cells = make(map[[2]int]board.Cell)
-		dst.BoundsValid = false
-		return
-	}
-
-	pad := 1 // extra cells around the bounding box for births
-
-	// Expand bounds by pad in each direction
-	sminR -= pad
-	sminC -= pad
-	smaxR += pad
-	smaxC += pad
-
-	rows := smaxR - sminR + 1
-	cols := smaxC - sminC + 1
-
-	n := rows * cols
-	srcFlat := make([]C.int, n)
-
-	// Fill flat src array - easier to work with here, and probably faster than 2d
-	for coord := range src.Cells {
-		r := coord[0] - sminR // shift by padded min
-		c := coord[1] - sminC
-		if r < 0 || r >= rows || c < 0 || c >= cols {
-			continue
-		}
-		srcFlat[r*cols+c] = 1
+// On a bounded grid the kernel runs over the whole grid and wraps neighbors at its edges;
+// on an unbounded one only the tiles holding cells are uploaded, in as many batches
+// as it takes to fit in device memory.
+// Tick uploads and downloads the board for a single generation; a Session keeps
+// it on the GPU for many. If the GPU fails, Tick returns the error, with the CUDA
+// error string, and dst is left unchanged.
+func Tick(src, dst board.Board, r *rule.Rule, t board.Topology) error {
+	dev := NewCUDADevice()
+	defer dev.Close()
+	if !t.Bounded() {
+		return newTiler(dev, r.Table(), r.States).step(src, dst)
 	}
-
-	dstFlat := make([]C.int, n)
-
-	// Do the thing on the GPU
-	C.tick(
-		(*C.int)(unsafe.Pointer(&srcFlat[0])),
-		(*C.int)(unsafe.Pointer(&dstFlat[0])),
-		C.int(rows),
-		C.int(cols),
-	)
-
-	// Map the GPU memory back into our host board structure
-	dst.Cells = make(map[[2]int]board.Cell)
-	dst.BoundsValid = false
-
-	for i := 0; i < n; i++ {
-		if dstFlat[i] != 0 {
-			r := i / cols
-			c := i % cols
-			gr := r + sminR
-			gc := c + sminC
-			dst.Set(gr, gc, true)
-		}
+	s, err := NewSession(dev, src, r, t)
+	if err != nil {
+		return err
 	}
-
-	if len(dst.Cells) == 0 {
-		dst.BoundsValid = true
-		dst.MinRow, dst.MaxRow, dst.MinCol, dst.MaxCol = 0, 0, 0, 0
+	if err := s.Step(1); err != nil {
+		return err
 	}
+	return s.Store(dst)
 }
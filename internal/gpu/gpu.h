@@ -1,2 +1,27 @@
 void square(float *a, int N);
-void tick(int *src, int *dst, int rows, int cols);
\ No newline at end of file
+// Bounded grid the kernel wraps neighbors around; kind follows board.TopologyKind,
+// where 0 (unbounded) and 1 (plane) both treat cells past the edges as dead.
+struct topology
+{
+  int kind;
+  int twist_rows;
+  int shift_col;
+  int shift_row;
+};
+
+// A session keeps a rows x cols grid of cell states on the device across
+// generations, in two buffers the kernel ping-pongs between, along with the
+// rule's transition table. The functions return a cudaError_t, 0 on success.
+struct session;
+
+int session_new(struct session **s, const int *cells, int rows, int cols, const unsigned char *table, int states, struct topology topo);
+int session_step(struct session *s, int n);
+int session_border_alive(struct session *s, int width, int *alive);
+int session_read(struct session *s, int *cells);
+void session_free(struct session *s);
+
+// tick_tiles advances a batch of count tiles of size x size cells by one generation.
+// in holds each tile with a one-cell halo of its neighbors, (size+2)x(size+2) cells,
+// and out receives the size x size cells of each tile's next generation.
+// Returns a cudaError_t, 0 on success.
+int tick_tiles(const int *in, int *out, int count, int size, const unsigned char *table, int states);
@@ -0,0 +1,32 @@
+//go:build cgo && !nocuda && !fakecuda
+
+package gpu
+
+// The kernels and their host drivers come from libgpu.so, built from gpu.cu (see
+// Makefile). It is opened at run time by libgpu_loader.c rather than linked, so the
+// package builds, and the game runs on the CPU, without it.
+
+/*
+#cgo CFLAGS: -I.
+#cgo LDFLAGS: -ldl
+#include <stdlib.h>
+#include "libgpu_loader.h"
+*/
+import "C"
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+// loadKernels opens the kernel library the first time it is called, and returns
+// the error it failed with, every time.
+var loadKernels = sync.OnceValue(func() error {
+	name := C.CString(kernelLib)
+	defer C.free(unsafe.Pointer(name))
+	var msg [512]C.char
+	if C.loadLibgpu(name, &msg[0], C.int(len(msg))) == 0 {
+		return errors.New(C.GoString(&msg[0]))
+	}
+	return nil
+})
@@ -0,0 +1,102 @@
+//go:build cgo && !nocuda && !fakecuda
+
+// libgpu_loader.c
+// The functions of gpu.h, forwarding to libgpu.so, which is opened with dlopen on
+// first use instead of being linked, so the package builds and its tests run
+// without the library.
+#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <stdio.h>
+#include "gpu.h"
+#include "libgpu_loader.h"
+
+typedef void (*square_t)(float *, int);
+typedef int (*session_new_t)(struct session **, const int *, int, int, const unsigned char *, int, struct topology);
+typedef int (*session_step_t)(struct session *, int);
+typedef int (*session_border_alive_t)(struct session *, int, int *);
+typedef int (*session_read_t)(struct session *, int *);
+typedef void (*session_free_t)(struct session *);
+typedef int (*tick_tiles_t)(const int *, int *, int, int, const unsigned char *, int);
+
+static void *libgpu = NULL;
+static square_t p_square;
+static session_new_t p_session_new;
+static session_step_t p_session_step;
+static session_border_alive_t p_session_border_alive;
+static session_read_t p_session_read;
+static session_free_t p_session_free;
+static tick_tiles_t p_tick_tiles;
+
+// cudaErrorInitializationError, for calls made before the library is loaded
+#define NOT_LOADED 3
+
+static void *lookup(const char *sym, char *err, int len)
+{
+  void *p = dlsym(libgpu, sym);
+  if (!p)
+    snprintf(err, len, "missing symbol %s", sym);
+  return p;
+}
+
+int loadLibgpu(const char *name, char *err, int len)
+{
+  if (libgpu)
+    return 1;
+  void *h = dlopen(name, RTLD_NOW | RTLD_LOCAL);
+  if (!h)
+  {
+    snprintf(err, len, "%s", dlerror());
+    return 0;
+  }
+  libgpu = h;
+  if (!(p_square = (square_t)lookup("square", err, len)) ||
+      !(p_session_new = (session_new_t)lookup("session_new", err, len)) ||
+      !(p_session_step = (session_step_t)lookup("session_step", err, len)) ||
+      !(p_session_border_alive = (session_border_alive_t)lookup("session_border_alive", err, len)) ||
+      !(p_session_read = (session_read_t)lookup("session_read", err, len)) ||
+      !(p_session_free = (session_free_t)lookup("session_free", err, len)) ||
+      !(p_tick_tiles = (tick_tiles_t)lookup("tick_tiles", err, len)))
+  {
+    dlclose(h);
+    libgpu = NULL;
+    return 0;
+  }
+  return 1;
+}
+
+void square(float *a, int N)
+{
+  if (libgpu)
+    p_square(a, N);
+}
+
+int session_new(struct session **s, const int *cells, int rows, int cols, const unsigned char *table, int states, struct topology topo)
+{
+  return libgpu ? p_session_new(s, cells, rows, cols, table, states, topo) : NOT_LOADED;
+}
+
+int session_step(struct session *s, int n)
+{
+  return libgpu ? p_session_step(s, n) : NOT_LOADED;
+}
+
+int session_border_alive(struct session *s, int width, int *alive)
+{
+  return libgpu ? p_session_border_alive(s, width, alive) : NOT_LOADED;
+}
+
+int session_read(struct session *s, int *cells)
+{
+  return libgpu ? p_session_read(s, cells) : NOT_LOADED;
+}
+
+void session_free(struct session *s)
+{
+  if (libgpu)
+    p_session_free(s);
+}
+
+int tick_tiles(const int *in, int *out, int count, int size, const unsigned char *table, int states)
+{
+  return libgpu ? p_tick_tiles(in, out, count, size, table, states) : NOT_LOADED;
+}
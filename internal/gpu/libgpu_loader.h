@@ -0,0 +1,8 @@
+#ifndef LIBGPU_LOADER_H
+#define LIBGPU_LOADER_H
+
+// loadLibgpu opens the named kernel library and looks up the functions of gpu.h
+// in it. It returns 1 on success, and 0 with a description in err otherwise.
+int loadLibgpu(const char *name, char *err, int len);
+
+#endif // LIBGPU_LOADER_H
@@ -0,0 +1,96 @@
+//go:build !cgo || nocuda
+
+package gpu
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// Without cgo, or with the nocuda build tag, the package builds without the CUDA
+// wrapper library: detection finds no GPU and the CUDA device fails every call,
+// so the game runs on the CPU. CPUDevice, Session and the tiling logic work as usual.
+
+// ErrNoCUDA is returned by the CUDA calls of a build without CUDA support.
+var ErrNoCUDA = errors.New("built without CUDA support")
+
+// noCUDALoader is the Loader of a build without CUDA support, which can't open
+// any library.
+type noCUDALoader struct{}
+
+// SystemLoader returns a Loader that fails with ErrNoCUDA, so HasCUDA is false.
+func SystemLoader() Loader {
+	return noCUDALoader{}
+}
+
+func (noCUDALoader) OpenDriver(name string) (Driver, error) {
+	return nil, ErrNoCUDA
+}
+
+func (noCUDALoader) OpenRuntime(name string) (Runtime, error) {
+	return nil, ErrNoCUDA
+}
+
+func (noCUDALoader) OpenKernels() error {
+	return ErrNoCUDA
+}
+
+// Initial demo only to get gpu stuff hooked up, not used
+func Square(a []float32) {
+	for i, x := range a {
+		a[i] = x * x
+	}
+}
+
+// noCUDADevice is the Device NewCUDADevice returns in a build without CUDA support.
+type noCUDADevice struct{}
+
+// NewCUDADevice returns a Device whose calls fail with ErrNoCUDA.
+func NewCUDADevice() Device {
+	return noCUDADevice{}
+}
+
+func (noCUDADevice) Load(cells []int32, rows, cols int, table [512]uint8, states int, t board.Topology) error {
+	return ErrNoCUDA
+}
+
+func (noCUDADevice) Step(n int) error {
+	return ErrNoCUDA
+}
+
+func (noCUDADevice) BorderAlive(width int) (bool, error) {
+	return false, ErrNoCUDA
+}
+
+func (noCUDADevice) Read(cells []int32) error {
+	return ErrNoCUDA
+}
+
+func (noCUDADevice) StepTiles(in, out []int32, count, size int, table [512]uint8, states int) error {
+	return ErrNoCUDA
+}
+
+func (noCUDADevice) Close() {}
+
+func CudaMalloc(devPtr *unsafe.Pointer, size uint64) error {
+	return ErrNoCUDA
+}
+
+func CudaFree(devPtr unsafe.Pointer) error {
+	return ErrNoCUDA
+}
+
+func CudaMemcpy(dst unsafe.Pointer, src unsafe.Pointer, count uint64, kind int) error {
+	return ErrNoCUDA
+}
+
+func CudaDeviceSynchronize() error {
+	return ErrNoCUDA
+}
+
+// CudaGetErrorString returns the description of a cudaError_t.
+func CudaGetErrorString(code int) string {
+	return "CUDA support not built in"
+}
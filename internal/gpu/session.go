@@ -0,0 +1,223 @@
+package gpu
+
+import (
+	"errors"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+const (
+	// sessionPad is the number of dead cells around a pattern when it is uploaded.
+	sessionPad = 64
+	// borderWidth is the band along the edges of the grid checked for live cells
+	// once the pattern may have grown into the padding.
+	borderWidth = sessionPad / 2
+	// maxDenseCells is the largest device grid an unbounded pattern is uploaded in.
+	maxDenseCells = 1 << 26
+	// sparseRatio is how many times larger than its occupied tiles the device grid
+	// of an unbounded pattern may be before the pattern is stepped in tiles instead.
+	sparseRatio = 64
+)
+
+// Session keeps a pattern on a Device across generations, so stepping many
+// generations costs one upload and one download instead of one of each per
+// generation. On an unbounded grid the device grid is the pattern's bounding box
+// plus padding; patterns grow by at most one cell per generation, so the session
+// knows how many generations it can run before a cell could reach the edge, then
+// checks the border on the device and only moves the pattern to a larger grid
+// when it is actually near the edge.
+//
+// A pattern whose bounding box is too large for the device, or mostly empty, like
+// a gun whose gliders have travelled far apart, is stepped in tiles instead: the
+// session keeps it on the host and only uploads the occupied tiles each generation.
+type Session struct {
+	dev   Device
+	rule  *rule.Rule
+	topo  board.Topology
+	table [512]uint8
+	cells []int32 // host copy of the grid, for uploads and downloads
+
+	// row0 and col0 are the board position of the grid's upper-left cell.
+	row0, col0 int
+	rows, cols int
+
+	// margin is the number of generations that can run before a live cell may
+	// reach the edge of the grid.
+	margin int
+
+	uploads int
+
+	// tiles steps the pattern in tiles, when it is not kept in a device grid,
+	// from host to scratch.
+	tiles         *tiler
+	host, scratch board.Board
+}
+
+// NewSession uploads a board to a device, to be stepped with a rule on a topology.
+// The rule must be supported (see Supports).
+func NewSession(dev Device, src board.Board, r *rule.Rule, t board.Topology) (*Session, error) {
+	s := &Session{dev: dev, rule: r, topo: t, table: r.Table()}
+	if err := s.upload(src); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// upload loads the cells of a board into a device grid that fits them, or
+// switches to stepping them in tiles when an unbounded one doesn't.
+func (s *Session) upload(src board.Board) error {
+	s.uploads++
+	var maxRow, maxCol int
+	if s.topo.Bounded() {
+		s.row0, s.col0, maxRow, maxCol = s.topo.Extent()
+	} else {
+		s.row0, s.col0, maxRow, maxCol = src.Bounds()
+		s.row0 -= sessionPad
+		s.col0 -= sessionPad
+		maxRow += sessionPad
+		maxCol += sessionPad
+	}
+	s.rows = maxRow - s.row0 + 1
+	s.cols = maxCol - s.col0 + 1
+	s.margin = sessionPad
+	if !s.topo.Bounded() {
+		if cells := s.rows * s.cols; cells > maxDenseCells || cells > sparseRatio*occupiedTiles(src)*tileSize*tileSize {
+			return s.startTiles(src)
+		}
+	}
+
+	s.cells = make([]int32, s.rows*s.cols)
+	for pos, state := range src.All() {
+		r, c := pos[0]-s.row0, pos[1]-s.col0
+		if r < 0 || r >= s.rows || c < 0 || c >= s.cols {
+			continue
+		}
+		s.cells[r*s.cols+c] = int32(state)
+	}
+	err := s.dev.Load(s.cells, s.rows, s.cols, s.table, s.rule.States, s.topo)
+	if errors.Is(err, ErrOutOfMemory) && !s.topo.Bounded() {
+		return s.startTiles(src)
+	}
+	return err
+}
+
+// startTiles switches the session to stepping a board's cells in tiles.
+func (s *Session) startTiles(src board.Board) error {
+	s.dev.Close()
+	s.cells = nil
+	s.rows, s.cols = 0, 0
+	s.tiles = newTiler(s.dev, s.table, s.rule.States)
+	s.host, s.scratch = src.Clone(), board.NewInfiniteGrid()
+	return nil
+}
+
+// occupiedTiles returns the number of tiles holding cells of a board.
+func occupiedTiles(src board.Board) int {
+	tiles := make(map[[2]int]struct{})
+	for pos := range src.All() {
+		tiles[tileOf(pos[0], pos[1])] = struct{}{}
+	}
+	return len(tiles)
+}
+
+// Step advances the pattern by n generations on the device.
+func (s *Session) Step(n int) error {
+	if s.tiles != nil {
+		for range n {
+			if err := s.tiles.step(s.host, s.scratch); err != nil {
+				return err
+			}
+			s.host, s.scratch = s.scratch, s.host
+		}
+		return nil
+	}
+	for n > 0 {
+		k := n
+		if !s.topo.Bounded() {
+			if s.margin == 0 {
+				if err := s.makeRoom(); err != nil {
+					return err
+				}
+			}
+			k = min(k, s.margin)
+			s.margin -= k
+		}
+		if err := s.dev.Step(k); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
+
+// makeRoom restores the margin, either because the border is still empty or by
+// moving the pattern to a grid with fresh padding around it.
+func (s *Session) makeRoom() error {
+	alive, err := s.dev.BorderAlive(borderWidth)
+	if err != nil {
+		return err
+	}
+	if !alive {
+		s.margin = borderWidth
+		return nil
+	}
+	g := board.NewInfiniteGrid()
+	if err := s.Store(g); err != nil {
+		return err
+	}
+	return s.upload(g)
+}
+
+// Store downloads the pattern into a board, replacing its contents.
+func (s *Session) Store(dst board.Board) error {
+	if s.tiles != nil {
+		dst.Clear()
+		for pos, state := range s.host.All() {
+			dst.Set(pos[0], pos[1], state)
+		}
+		return nil
+	}
+	if err := s.dev.Read(s.cells); err != nil {
+		return err
+	}
+	dst.Clear()
+	for i, state := range s.cells {
+		if state != 0 {
+			dst.Set(s.row0+i/s.cols, s.col0+i%s.cols, board.Cell(state))
+		}
+	}
+	return nil
+}
+
+// Rule returns the rule the session runs.
+func (s *Session) Rule() *rule.Rule {
+	return s.rule
+}
+
+// Topology returns the topology the session runs on.
+func (s *Session) Topology() board.Topology {
+	return s.topo
+}
+
+// Size returns the size of the device grid, or 0, 0 when the pattern is stepped in tiles.
+func (s *Session) Size() (rows, cols int) {
+	return s.rows, s.cols
+}
+
+// Tiled reports whether the pattern is stepped in tiles rather than kept in a device grid.
+func (s *Session) Tiled() bool {
+	return s.tiles != nil
+}
+
+// Uploads returns the number of times the pattern was loaded onto the device,
+// counting the first upload and every move to a larger grid, but not the tiles
+// uploaded every generation once the pattern is stepped in tiles.
+func (s *Session) Uploads() int {
+	return s.uploads
+}
+
+// Close frees the device grid.
+func (s *Session) Close() {
+	s.dev.Close()
+}
@@ -0,0 +1,152 @@
+package gpu
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+const (
+	// tileSize is the side of the square tiles a sparse pattern is uploaded in.
+	tileSize = 32
+	// tileStride is the side of a tile with its one-cell halo.
+	tileStride = tileSize + 2
+	// maxTileBatch is the largest number of tiles sent to the device at once.
+	maxTileBatch = 1024
+)
+
+// tiler steps an unbounded pattern on a Device one generation at a time,
+// uploading only the tiles that hold cells and the tiles their live cells may
+// spread into, each with a halo of the cells around it. Gliders that have
+// travelled far apart cost a few tiles each instead of the whole bounding box.
+type tiler struct {
+	dev    Device
+	table  [512]uint8
+	states int
+
+	// batch is the number of tiles sent to the device at once, halved whenever
+	// the device runs out of memory.
+	batch int
+
+	// Scratch storage reused across generations
+	index   map[[2]int]int
+	keys    [][2]int
+	in, out []int32
+}
+
+func newTiler(dev Device, table [512]uint8, states int) *tiler {
+	return &tiler{dev: dev, table: table, states: states, batch: maxTileBatch, index: make(map[[2]int]int)}
+}
+
+func tileOf(row, col int) [2]int {
+	return [2]int{floorDiv(row, tileSize), floorDiv(col, tileSize)}
+}
+
+// step writes the generation after src to dst. dst is only touched once the
+// device has computed every tile, so on error it is left as it was.
+func (t *tiler) step(src, dst board.Board) error {
+	// Tiles to compute: the occupied ones, and the ones live cells on their
+	// edges may give birth in
+	clear(t.index)
+	t.keys = t.keys[:0]
+	add := func(key [2]int) {
+		if _, ok := t.index[key]; !ok {
+			t.index[key] = len(t.keys)
+			t.keys = append(t.keys, key)
+		}
+	}
+	for pos, state := range src.All() {
+		key := tileOf(pos[0], pos[1])
+		add(key)
+		if state.IsAlive() && onTileEdge(pos[0], pos[1], key) {
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					add(tileOf(pos[0]+dr, pos[1]+dc))
+				}
+			}
+		}
+	}
+	count := len(t.keys)
+	if count == 0 {
+		dst.Clear()
+		return nil
+	}
+
+	// Each cell goes into its own tile and, on an edge, the halos of the tiles around it
+	t.in = resize(t.in, count*tileStride*tileStride)
+	t.out = resize(t.out, count*tileSize*tileSize)
+	for pos, state := range src.All() {
+		key := tileOf(pos[0], pos[1])
+		spread := 0
+		if onTileEdge(pos[0], pos[1], key) {
+			spread = 1
+		}
+		for dr := -spread; dr <= spread; dr++ {
+			for dc := -spread; dc <= spread; dc++ {
+				r := pos[0] - (key[0]+dr)*tileSize + 1
+				c := pos[1] - (key[1]+dc)*tileSize + 1
+				if r < 0 || r >= tileStride || c < 0 || c >= tileStride {
+					continue
+				}
+				if i, ok := t.index[[2]int{key[0] + dr, key[1] + dc}]; ok {
+					t.in[(i*tileStride+r)*tileStride+c] = int32(state)
+				}
+			}
+		}
+	}
+
+	for done := 0; done < count; {
+		n := min(t.batch, count-done)
+		in := t.in[done*tileStride*tileStride : (done+n)*tileStride*tileStride]
+		out := t.out[done*tileSize*tileSize : (done+n)*tileSize*tileSize]
+		err := t.dev.StepTiles(in, out, n, tileSize, t.table, t.states)
+		if err == nil {
+			done += n
+			continue
+		}
+		if !errors.Is(err, ErrOutOfMemory) {
+			return err
+		}
+		if n == 1 {
+			return fmt.Errorf("a single %dx%d tile doesn't fit: %w", tileSize, tileSize, err)
+		}
+		// Retry with smaller batches, and keep to them for later generations
+		t.batch = n / 2
+	}
+
+	dst.Clear()
+	for i, key := range t.keys {
+		cells := t.out[i*tileSize*tileSize : (i+1)*tileSize*tileSize]
+		for j, state := range cells {
+			if state != 0 {
+				dst.Set(key[0]*tileSize+j/tileSize, key[1]*tileSize+j%tileSize, board.Cell(state))
+			}
+		}
+	}
+	return nil
+}
+
+// onTileEdge reports whether a cell of a tile has neighbors in other tiles.
+func onTileEdge(row, col int, key [2]int) bool {
+	return row == key[0]*tileSize || row == (key[0]+1)*tileSize-1 ||
+		col == key[1]*tileSize || col == (key[1]+1)*tileSize-1
+}
+
+// resize returns a zeroed slice of n cells, reusing s's storage when it can.
+func resize(s []int32, n int) []int32 {
+	if cap(s) < n {
+		return make([]int32, n)
+	}
+	s = s[:n]
+	clear(s)
+	return s
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
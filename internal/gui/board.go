@@ -10,6 +10,7 @@ import (
 	"gioui.org/io/event"
 	"gioui.org/layout"
 	"gioui.org/op/paint"
+	"github.com/kvitebjorn/gol/internal/board"
 )
 
 func computeDynamicView(gtx layout.Context, zoom float64, panX, panY int) (
@@ -19,8 +20,9 @@ func computeDynamicView(gtx layout.Context, zoom float64, panX, panY int) (
 
 	cellSizeF := zoom * 20
 	cellSize = max(min(int(cellSizeF), 50), 2)
+	pitch := colPitch(currentLattice(), cellSize)
 
-	cols := availableWidth / cellSize
+	cols := availableWidth / pitch
 	rows := availableHeight / cellSize
 
 	centerRow := panY
@@ -32,12 +34,29 @@ func computeDynamicView(gtx layout.Context, zoom float64, panX, panY int) (
 	maxCol = minCol + cols
 
 	margin = 0
-	width = cols*cellSize + 2*margin
+	width = cols*pitch + 2*margin
 	height = rows*cellSize + 2*margin
 
 	return
 }
 
+// stateColor returns the fill color for a cell state. Live cells are green, and the
+// dying states of Generations rules fade from red towards the background color.
+func stateColor(state board.Cell, states int) color.NRGBA {
+	if state.IsAlive() || states <= 2 {
+		return color.NRGBA{R: 0, G: 200, B: 0, A: 255}
+	}
+	// t runs from 0 for the first dying state to 1 for the last one
+	t := 0.0
+	if states > 3 {
+		t = float64(state-2) / float64(states-3)
+	}
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + t*(float64(b)-float64(a)))
+	}
+	return color.NRGBA{R: lerp(200, 190), G: lerp(40, 190), B: lerp(0, 230), A: 255}
+}
+
 func LayoutBoard(
 	gtx layout.Context,
 	cache *viewCache,
@@ -77,58 +96,74 @@ func LayoutBoard(
 
 				bg := image.NewUniform(color.NRGBA{R: 220, G: 220, B: 220, A: 255})
 				draw.Draw(img, img.Bounds(), bg, image.Point{}, draw.Src)
+				topo := gameState.ActiveTopology()
+				if topo.Bounded() {
+					outside := color.NRGBA{R: 150, G: 150, B: 150, A: 255}
+					shadeOutside(img, outside, currentLattice(), topo, minRow, minCol, maxRow, cellSize)
+				}
 
 				// Render an entire row at once
 				// This is more efficient than rendering cell by cell!
 				// And only render alive cells within the view port!!!
-				colsByRow := map[int][]int{}
-				for _, p := range gameState.CurrentBoard().AliveCellsWithinBounds(minCol, minRow, maxCol, maxRow) {
+				// Runs are split by state so each state gets its own color.
+				cur := gameState.CurrentBoard()
+				lattice := currentLattice()
+				loCol, hiCol := visibleCols(lattice, minRow, minCol, maxRow, maxCol)
+				colsByStateRow := map[board.Cell]map[int][]int{}
+				for _, p := range cur.AliveCellsWithinBounds(loCol, minRow, hiCol, maxRow) {
 					r := p[0]
 					c := p[1]
-					colsByRow[r] = append(colsByRow[r], c)
+					state := cur.At(r, c)
+					if colsByStateRow[state] == nil {
+						colsByStateRow[state] = map[int][]int{}
+					}
+					colsByStateRow[state][r] = append(colsByStateRow[state][r], c)
 				}
 
-				fillCol := image.NewUniform(color.NRGBA{R: 0, G: 200, B: 0, A: 255})
-				for r, cols := range colsByRow {
-					if len(cols) == 0 {
-						continue
-					}
-					sort.Ints(cols)
-					start := cols[0]
-					last := start
-					y := margin + (r-minRow)*cellSize
-					for i := 1; i < len(cols); i++ {
-						if cols[i] == last || cols[i] == last+1 {
-							last = cols[i]
+				states := gameState.ActiveRule().States
+				for state, colsByRow := range colsByStateRow {
+					fillCol := image.NewUniform(stateColor(state, states))
+					for r, cols := range colsByRow {
+						if len(cols) == 0 {
 							continue
 						}
+						if lattice.IsTriangular() {
+							// Triangles interlock, so they can't be merged into rectangles
+							for _, c := range cols {
+								fillTriangle(img, fillCol, lattice, r, c, minRow, minCol, cellSize)
+							}
+							continue
+						}
+						sort.Ints(cols)
+						start := cols[0]
+						last := start
+						y := margin + (r-minRow)*cellSize
+						for i := 1; i < len(cols); i++ {
+							if cols[i] == last || cols[i] == last+1 {
+								last = cols[i]
+								continue
+							}
+
+							x := margin + cellX(lattice, r, start, minCol, cellSize)
+							wPixels := (last - start + 1) * cellSize
+							rect := image.Rect(x, y, x+wPixels, y+cellSize)
+							draw.Draw(img, rect, fillCol, image.Point{}, draw.Src)
+							start = cols[i]
+							last = cols[i]
+						}
 
-						x := margin + (start-minCol)*cellSize
+						x := margin + cellX(lattice, r, start, minCol, cellSize)
 						wPixels := (last - start + 1) * cellSize
 						rect := image.Rect(x, y, x+wPixels, y+cellSize)
 						draw.Draw(img, rect, fillCol, image.Point{}, draw.Src)
-						start = cols[i]
-						last = cols[i]
 					}
-
-					x := margin + (start-minCol)*cellSize
-					wPixels := (last - start + 1) * cellSize
-					rect := image.Rect(x, y, x+wPixels, y+cellSize)
-					draw.Draw(img, rect, fillCol, image.Point{}, draw.Src)
-				}
-
-				gridCol := image.NewUniform(color.NRGBA{R: 180, G: 180, B: 180, A: 255})
-
-				for i := 0; i <= (maxRow - minRow); i++ {
-					y := margin + i*cellSize
-					rect := image.Rect(margin, y, margin+(maxCol-minCol)*cellSize, y+1)
-					draw.Draw(img, rect, gridCol, image.Point{}, draw.Src)
 				}
 
-				for j := 0; j <= (maxCol - minCol); j++ {
-					x := margin + j*cellSize
-					rect := image.Rect(x, margin, x+1, margin+(maxRow-minRow)*cellSize)
-					draw.Draw(img, rect, gridCol, image.Point{}, draw.Src)
+				gridCol := color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+				drawGrid(img, gridCol, lattice, minRow, minCol, maxRow, maxCol, cellSize)
+				if topo.Bounded() {
+					edgeCol := color.NRGBA{R: 200, G: 0, B: 0, A: 255}
+					drawEdges(img, edgeCol, lattice, topo, minRow, minCol, maxRow, cellSize)
 				}
 
 				cache.img = img
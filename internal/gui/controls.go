@@ -1,6 +1,8 @@
 package gui
 
 import (
+	"bytes"
+	"fmt"
 	"time"
 
 	"image/color"
@@ -11,15 +13,22 @@ import (
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 
-	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
 	"github.com/kvitebjorn/gol/internal/util"
 )
 
 var (
+	backButton      widget.Clickable
 	nextButton      widget.Clickable
+	jumpButton      widget.Clickable
 	playPauseButton widget.Clickable
 	resetButton     widget.Clickable
 	importButton    widget.Clickable
+	exportButton    widget.Clickable
+	undoButton      widget.Clickable
+	redoButton      widget.Clickable
+	timeline        widget.Float
 )
 
 func LayoutControls(gtx layout.Context, th *material.Theme, w *app.Window) layout.Dimensions {
@@ -30,43 +39,119 @@ func LayoutControls(gtx layout.Context, th *material.Theme, w *app.Window) layou
 		Right:  unit.Dp(10),
 	}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{
-			Axis:      layout.Horizontal,
-			Spacing:   layout.SpaceSides,
-			Alignment: layout.Middle,
+			Axis: layout.Vertical,
 		}.Layout(gtx,
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				btn := material.Button(th, &nextButton, "Next")
-				if playing && !paused {
-					btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
-				}
-				return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
-			}),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				btn := material.Button(th, &playPauseButton, func() string {
-					if !playing {
-						return "Play"
-					} else if paused {
-						return "Resume"
-					} else {
-						return "Pause"
-					}
-				}())
-				return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+				return layoutTimeline(gtx, th)
 			}),
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				btn := material.Button(th, &resetButton, "Reset")
-				return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
-			}),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				btn := material.Button(th, &importButton, "Import")
-				return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+				return layoutButtons(gtx, th)
 			}),
 		)
 	})
 }
 
+// layoutTimeline lays out the slider that scrubs through the game's history,
+// between the generations at its ends.
+func layoutTimeline(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	h := gameState.History
+	if !timeline.Dragging() {
+		timeline.Value = timelinePos()
+	}
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			label := material.Body2(th, util.Itoa(h.Oldest()))
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, label.Layout)
+		}),
+		layout.Flexed(1, material.Slider(th, &timeline).Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			label := material.Body2(th, util.Itoa(h.Newest()))
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, label.Layout)
+		}),
+	)
+}
+
+// layoutButtons lays out the row of playback buttons.
+func layoutButtons(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Spacing:   layout.SpaceSides,
+		Alignment: layout.Middle,
+	}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &backButton, "Back")
+			if playing && !paused {
+				btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+			}
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &nextButton, "Next")
+			if playing && !paused {
+				btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+			}
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			label := fmt.Sprintf("Jump 2^%d", jumpExp)
+			if jumping() {
+				label = fmt.Sprintf("Cancel %d%%", jumpPercent())
+			}
+			btn := material.Button(th, &jumpButton, label)
+			if playing && !paused {
+				btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+			}
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &playPauseButton, func() string {
+				if !playing {
+					return "Play"
+				} else if paused {
+					return "Resume"
+				} else {
+					return "Pause"
+				}
+			}())
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &resetButton, "Reset")
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &importButton, "Import")
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &exportButton, "Export")
+			if !canEdit() {
+				btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+			}
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &undoButton, "Undo")
+			if !canEdit() || !edits.CanUndo(&gameState) {
+				btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+			}
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			btn := material.Button(th, &redoButton, "Redo")
+			if !canEdit() || !edits.CanRedo(&gameState) {
+				btn.Background = color.NRGBA{R: 180, G: 180, B: 180, A: 255}
+			}
+			return layout.UniformInset(unit.Dp(4)).Layout(gtx, btn.Layout)
+		}),
+	)
+}
+
 func HandleControlClicks(gtx C, cache *viewCache, w *app.Window) {
-	if playPauseButton.Clicked(gtx) {
+	if playPauseButton.Clicked(gtx) && !jumping() {
 		if !playing {
 			playing = true
 			paused = false
@@ -112,52 +197,123 @@ func HandleControlClicks(gtx C, cache *viewCache, w *app.Window) {
 	}
 	if resetButton.Clicked(gtx) {
 		stopPlayback()
-		gameState = game.Game{
-			BoardA: initialBoard.DeepCopy(),
-			BoardB: initialBoard.DeepCopy(),
-			UseA:   true,
-			Turn:   1,
-		}
+		gameState.Close()
+		gameState = newGame()
+		// The edits were made to the game that was reset
+		edits.Clear()
 		zoomLevel = 1.0
 		panX = 0
 		panY = 0
 		w.Invalidate()
 	}
-	if nextButton.Clicked(gtx) && (!playing || paused) {
+	if undoButton.Clicked(gtx) {
+		undoEdit(cache, w)
+	}
+	if redoButton.Clicked(gtx) {
+		redoEdit(cache, w)
+	}
+	if nextButton.Clicked(gtx) && (!playing || paused) && !jumping() {
 		gameState.Tick()
 		w.Invalidate()
 	}
+	// Going back in the history only while the game is still
+	if backButton.Clicked(gtx) && (!playing || paused) && !jumping() {
+		if gameState.Turn > gameState.History.Oldest() {
+			setTimelineErr(gameState.Rewind(1))
+		}
+		w.Invalidate()
+	}
+	if timeline.Update(gtx) && (!playing || paused) && !jumping() {
+		if gen := timelineGen(timeline.Value); gen != gameState.Turn {
+			setTimelineErr(gameState.Seek(gen))
+		}
+		w.Invalidate()
+	}
+	if jumpButton.Clicked(gtx) {
+		if jumping() {
+			jumpCancel()
+		} else if !playing || paused {
+			startJump(w)
+		}
+		w.Invalidate()
+	}
 	if importButton.Clicked(gtx) && !fileDialogActive {
 		fileDialogActive = true
 		go func(win *app.Window) {
-			explorer := GetExplorerInstance(win)
-			r, err := explorer.ChooseFile(".rle")
-			if err != nil {
-				fileReadErr = err
-				fileDialogActive = false
-				return
-			}
-			defer r.Close()
-			b, err := util.ImportRLE(r)
-			if err != nil {
-				fileReadErr = err
-			} else {
-				initialBoard = b.DeepCopy()
-				fileReadErr = nil
-				stopPlayback()
-				gameState = game.Game{
-					BoardA: initialBoard.DeepCopy(),
-					BoardB: initialBoard.DeepCopy(),
-					UseA:   true,
-					Turn:   1,
-				}
-				zoomLevel = 1.0
-				panX = 0
-				panY = 0
-				cache.img = nil
-				win.Invalidate()
-			}
-			fileDialogActive = false
+			imports <- readImport(win)
+			win.Invalidate()
 		}(w)
 	}
+	// The pattern is written out while the game is still, and saved in the background
+	if exportButton.Clicked(gtx) && !fileDialogActive && canEdit() {
+		var buf bytes.Buffer
+		if err := util.ExportRLE(&buf, gameState.CurrentBoard(), gameState.ActiveRule()); err != nil {
+			setExportErr(err)
+		} else {
+			fileDialogActive = true
+			go func(win *app.Window) {
+				exports <- writeExport(win, buf.Bytes())
+				win.Invalidate()
+			}(w)
+		}
+	}
+	select {
+	case res := <-imports:
+		applyImport(res, cache)
+		w.Invalidate()
+	case err := <-exports:
+		fileDialogActive = false
+		setExportErr(err)
+		w.Invalidate()
+	default:
+	}
+}
+
+// writeExport lets the user choose where to save an RLE file, and writes data to it.
+func writeExport(w *app.Window, data []byte) error {
+	explorer := GetExplorerInstance(w)
+	f, err := explorer.CreateFile("pattern.rle")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// importResult is a pattern read from an RLE file, or the error reading it failed with.
+type importResult struct {
+	board board.Board
+	rule  *rule.Rule
+	err   error
+}
+
+// readImport lets the user choose an RLE file and reads the pattern in it.
+func readImport(w *app.Window) importResult {
+	explorer := GetExplorerInstance(w)
+	r, err := explorer.ChooseFile(".rle")
+	if err != nil {
+		return importResult{err: err}
+	}
+	defer r.Close()
+	b, rl, err := util.ImportRLE(r)
+	return importResult{board: b, rule: rl, err: err}
+}
+
+// applyImport replaces the game with one started from an imported pattern. It
+// runs on the UI goroutine, as it changes the game.
+func applyImport(res importResult, cache *viewCache) {
+	fileDialogActive = false
+	fileReadErr = res.err
+	if res.err != nil {
+		return
+	}
+	stopPlayback()
+	edits.Do(&gameState, &importEdit{board: res.board, rule: res.rule})
+	zoomLevel = 1.0
+	panX = 0
+	panY = 0
+	cache.img = nil
 }
@@ -0,0 +1,64 @@
+package gui
+
+import (
+	"gioui.org/app"
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// Edits made by hand, as opposed to generations, go through the edit log so they
+// can be undone with Ctrl+Z and redone with Ctrl+Shift+Z or the toolbar buttons.
+var edits game.EditLog
+
+// importEdit replaces the game with one started from an imported pattern, and
+// brings the game it replaced back when reverted: its initial pattern and rule,
+// and the generation it was at, without the history before it.
+type importEdit struct {
+	board board.Board
+	rule  *rule.Rule
+
+	prevInitial board.Board
+	prevRule    *rule.Rule
+	prevBoard   board.Board
+	prevTurn    int
+}
+
+func (e *importEdit) Apply(g *game.Game) {
+	e.prevInitial, e.prevRule = initialBoard, activeRule
+	e.prevBoard, e.prevTurn = g.CurrentBoard().Clone(), g.Turn
+	g.Close()
+	initialBoard = e.board.Clone()
+	if e.rule != nil {
+		activeRule = e.rule
+	}
+	*g = newGame()
+}
+
+func (e *importEdit) Revert(g *game.Game) {
+	g.Close()
+	initialBoard, activeRule = e.prevInitial, e.prevRule
+	*g = gameAt(e.prevBoard, e.prevTurn)
+	e.prevInitial, e.prevRule, e.prevBoard = nil, nil, nil
+}
+
+// canEdit reports whether the game is still, so edits can be made and undone.
+func canEdit() bool {
+	return (!playing || paused) && !jumping()
+}
+
+// undoEdit reverts the last edit, if the game is at the generation it was made at.
+func undoEdit(cache *viewCache, w *app.Window) {
+	if canEdit() && edits.Undo(&gameState) {
+		cache.img = nil
+		w.Invalidate()
+	}
+}
+
+// redoEdit applies the last edit undone again.
+func redoEdit(cache *viewCache, w *app.Window) {
+	if canEdit() && edits.Redo(&gameState) {
+		cache.img = nil
+		w.Invalidate()
+	}
+}
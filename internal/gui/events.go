@@ -4,6 +4,8 @@ import (
 	"gioui.org/app"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
 )
 
 func HandleEvents(gtx C, cache *viewCache, w *app.Window) {
@@ -11,12 +13,25 @@ func HandleEvents(gtx C, cache *viewCache, w *app.Window) {
 	for {
 		ev, ok := gtx.Event(key.Filter{
 			Optional: key.ModShift,
+		}, key.Filter{
+			Name:     "Z",
+			Required: key.ModShortcut,
+			Optional: key.ModShift,
 		})
 		if !ok {
 			break
 		}
 		if kev, ok := ev.(key.Event); ok {
 			switch kev.Name {
+			case "Z":
+				if kev.State != key.Press || !kev.Modifiers.Contain(key.ModShortcut) {
+					break
+				}
+				if kev.Modifiers.Contain(key.ModShift) {
+					redoEdit(cache, w)
+				} else {
+					undoEdit(cache, w)
+				}
 			case key.NameUpArrow:
 				panY -= 4
 				changed = true
@@ -38,6 +53,16 @@ func HandleEvents(gtx C, cache *viewCache, w *app.Window) {
 				if zoomLevel != old {
 					changed = true
 				}
+			case "[":
+				if jumpExp > 0 {
+					jumpExp--
+					changed = true
+				}
+			case "]":
+				if jumpExp < game.MaxFastForward {
+					jumpExp++
+					changed = true
+				}
 			case "-":
 				old := zoomLevel
 				zoomLevel *= 0.9
@@ -94,14 +119,19 @@ func HandleBoardEvents(gtx C, cache *viewCache, w *app.Window) {
 				minRow, minCol, _, _, cellSize, _, _, _ :=
 					computeDynamicView(gtx, zoomLevel, panX, panY)
 
-				cellCol := int(clickX) / cellSize
-				cellRow := int(clickY) / cellSize
-
-				row := minRow + cellRow
-				col := minCol + cellCol
+				row, col := cellAt(currentLattice(), int(clickX), int(clickY), minRow, minCol, cellSize)
+				if !gameState.ActiveTopology().Contains(row, col) {
+					// Cells past the edges of a bounded grid can't be edited
+					break
+				}
 
-				cur := gameState.CurrentBoard().At(row, col)
-				gameState.CurrentBoard().Set(row, col, !cur)
+				next := board.Alive
+				if gameState.CurrentBoard().At(row, col) != board.Dead {
+					next = board.Dead
+				}
+				var e game.CellEdit
+				e.Set(row, col, next)
+				edits.Do(&gameState, &e)
 
 				cache.img = nil
 				w.Invalidate()
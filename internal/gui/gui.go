@@ -6,37 +6,33 @@ import (
 
 	"gioui.org/app"
 	"github.com/kvitebjorn/gol/internal/board"
-	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
 )
 
-func RunGUI(imported *board.InfiniteGrid) {
+func RunGUI(imported board.Board, r *rule.Rule) {
 	go func() {
 		w := new(app.Window)
 		w.Option(app.Title("Game of Life"))
 		w.Option(app.Maximized.Option())
 
-		var ig board.InfiniteGrid
+		var ig board.Board
 		if imported != nil {
-			ig = imported.DeepCopy()
+			ig = imported.Clone()
 		} else {
 			// Default: glider
 			initial := [][2]int{
 				{0, 1}, {1, 2}, {2, 0}, {2, 1}, {2, 2},
 			}
-			ig = board.NewInfiniteGrid()
+			ig = board.NewDefault()
 			for _, p := range initial {
-				ig.Set(p[0], p[1], true)
+				ig.Set(p[0], p[1], board.Alive)
 			}
 		}
 
-		initialBoard = ig.DeepCopy()
+		initialBoard = ig.Clone()
+		activeRule = r
 
-		gameState = game.Game{
-			BoardA: initialBoard.DeepCopy(),
-			BoardB: initialBoard.DeepCopy(),
-			UseA:   true,
-			Turn:   1,
-		}
+		gameState = newGame()
 		if err := runWindow(w); err != nil {
 			log.Fatal(err)
 		}
@@ -0,0 +1,185 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// The board is always stored as a square grid of (row, col) cells; these helpers map
+// cells to view pixels for the lattice of the active rule.
+//
+// Hexagonal cells are drawn as bricks: row r is shifted left by half a cell per row
+// (wrapped every two rows so the view stays rectangular), which puts the NE and SW
+// cells that hexagonal rules ignore out of contact with the cell.
+//
+// Triangular cells are drawn as triangles with a base of two columns, pointing up
+// when row+col is even, so neighboring triangles in a row interlock.
+
+func currentLattice() rule.Neighborhood {
+	return gameState.ActiveRule().Neighborhood
+}
+
+// colPitch returns the horizontal distance in pixels between neighboring columns.
+func colPitch(lattice rule.Neighborhood, cellSize int) int {
+	if lattice.IsTriangular() {
+		return max(cellSize/2, 1)
+	}
+	return cellSize
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// hexShift returns the number of columns a hexagonal row is offset by, and whether it
+// is additionally shifted left by half a cell.
+func hexShift(row int) (cols int, half bool) {
+	return floorDiv(row, 2), row&1 == 1
+}
+
+// cellX returns the x pixel of the left edge of a cell's column span.
+func cellX(lattice rule.Neighborhood, row, col, minCol, cellSize int) int {
+	switch {
+	case lattice == rule.Hexagonal:
+		shift, half := hexShift(row)
+		x := (col - shift - minCol) * cellSize
+		if half {
+			x -= cellSize / 2
+		}
+		return x
+	case lattice.IsTriangular():
+		return (col - minCol) * colPitch(lattice, cellSize)
+	}
+	return (col - minCol) * cellSize
+}
+
+// visibleCols returns the range of board columns that may be visible in a view,
+// which for hexagonal rows depends on the row offsets.
+func visibleCols(lattice rule.Neighborhood, minRow, minCol, maxRow, maxCol int) (int, int) {
+	switch {
+	case lattice == rule.Hexagonal:
+		lo, _ := hexShift(minRow)
+		hi, _ := hexShift(maxRow)
+		return minCol + lo, maxCol + hi + 1
+	case lattice.IsTriangular():
+		// A triangle's span reaches into the next column
+		return minCol - 1, maxCol
+	}
+	return minCol, maxCol
+}
+
+// cellAt returns the board cell under a view pixel.
+func cellAt(lattice rule.Neighborhood, px, py, minRow, minCol, cellSize int) (row, col int) {
+	row = minRow + floorDiv(py, cellSize)
+	switch {
+	case lattice == rule.Hexagonal:
+		shift, half := hexShift(row)
+		if half {
+			px += cellSize / 2
+		}
+		col = minCol + floorDiv(px, cellSize) + shift
+	case lattice.IsTriangular():
+		pitch := colPitch(lattice, cellSize)
+		y := py - floorDiv(py, cellSize)*cellSize
+		k := minCol + floorDiv(px, pitch)
+		// The pixel lies in the span of column k or k-1; pick the triangle containing it
+		for _, c := range []int{k, k - 1} {
+			cx := cellX(lattice, row, c, minCol, cellSize) + pitch
+			if abs(px-cx) <= triangleHalfWidth(rule.PointsUp(row, c), y, cellSize, pitch) {
+				return row, c
+			}
+		}
+		col = k
+	default:
+		col = minCol + floorDiv(px, cellSize)
+	}
+	return row, col
+}
+
+// triangleHalfWidth returns the half width of a triangle at pixel line y from its top.
+func triangleHalfWidth(up bool, y, cellSize, pitch int) int {
+	if up {
+		return pitch * (y + 1) / cellSize
+	}
+	return pitch * (cellSize - y) / cellSize
+}
+
+// fillTriangle draws the triangle at (row, col) one scanline at a time.
+func fillTriangle(img draw.Image, src image.Image, lattice rule.Neighborhood, row, col, minRow, minCol, cellSize int) {
+	pitch := colPitch(lattice, cellSize)
+	cx := cellX(lattice, row, col, minCol, cellSize) + pitch
+	top := (row - minRow) * cellSize
+	up := rule.PointsUp(row, col)
+	for y := 0; y < cellSize; y++ {
+		hw := triangleHalfWidth(up, y, cellSize, pitch)
+		rect := image.Rect(cx-hw, top+y, cx+hw, top+y+1)
+		draw.Draw(img, rect, src, image.Point{}, draw.Src)
+	}
+}
+
+// drawGrid draws the cell outlines of the lattice.
+func drawGrid(img *image.RGBA, gridCol color.NRGBA, lattice rule.Neighborhood, minRow, minCol, maxRow, maxCol, cellSize int) {
+	src := image.NewUniform(gridCol)
+	rows := maxRow - minRow
+	width := img.Bounds().Dx()
+
+	for i := 0; i <= rows; i++ {
+		y := i * cellSize
+		rect := image.Rect(0, y, width, y+1)
+		draw.Draw(img, rect, src, image.Point{}, draw.Src)
+	}
+
+	switch {
+	case lattice == rule.Hexagonal:
+		// Vertical edges are offset by half a cell on odd rows
+		for i := 0; i < rows; i++ {
+			_, half := hexShift(minRow + i)
+			offset := 0
+			if half {
+				offset = cellSize / 2
+			}
+			for j := 0; j <= maxCol-minCol+1; j++ {
+				x := j*cellSize - offset
+				rect := image.Rect(x, i*cellSize, x+1, (i+1)*cellSize)
+				draw.Draw(img, rect, src, image.Point{}, draw.Src)
+			}
+		}
+	case lattice.IsTriangular():
+		// Edges between neighboring triangles are diagonals, drawn a pixel at a time
+		pitch := colPitch(lattice, cellSize)
+		for i := 0; i < rows; i++ {
+			row := minRow + i
+			for k := 0; k <= maxCol-minCol+1; k++ {
+				// The edge between columns k-1 and k leans right if k-1 points up
+				leftUp := rule.PointsUp(row, minCol+k-1)
+				for y := 0; y < cellSize; y++ {
+					x := k*pitch + pitch*y/cellSize
+					if !leftUp {
+						x = (k+1)*pitch - pitch*y/cellSize
+					}
+					img.Set(x, i*cellSize+y, gridCol)
+				}
+			}
+		}
+	default:
+		for j := 0; j <= maxCol-minCol; j++ {
+			x := j * cellSize
+			rect := image.Rect(x, 0, x+1, rows*cellSize)
+			draw.Draw(img, rect, src, image.Point{}, draw.Src)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -1,16 +1,22 @@
 package gui
 
 import (
+	"context"
+	"errors"
 	"image"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gioui.org/app"
 	"gioui.org/f32"
 	"gioui.org/widget"
 	"gioui.org/x/explorer"
+	"github.com/kvitebjorn/gol/internal/analysis"
 	"github.com/kvitebjorn/gol/internal/board"
 	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
 )
 
 type viewCache struct {
@@ -36,9 +42,21 @@ var (
 	paused     bool
 	playStopCh chan struct{}
 
-	// Game state
+	// Jumps advance 2^jumpExp generations at once in the background, on a copy of
+	// the game that takes its history along. The copy comes back over jumpResult
+	// once the jump returns, which a cancelled jump only does after the batch of
+	// generations it is computing. jumpDone is written by the jump as it goes.
+	jumpExp    = 10
+	jumpCancel context.CancelFunc
+	jumpResult chan game.Game
+	jumpDone   atomic.Int64
+	jumpTotal  int64
+
+	// Game state. The game records its last historyLength generations, so the
+	// timeline can go back to them.
 	gameState    game.Game
-	initialBoard board.InfiniteGrid
+	initialBoard board.Board
+	activeRule   *rule.Rule
 
 	// Board clickable tag
 	boardTag       = new(bool)
@@ -46,7 +64,26 @@ var (
 
 	// File dialog related
 	fileReadErr      error
+	fileWriteErr     error
 	fileDialogActive bool
+	// imports carries the pattern read by the file dialog's goroutine to the
+	// UI goroutine, which applies it, and exports the outcome of saving one.
+	// Only one dialog is open at a time.
+	imports = make(chan importResult, 1)
+	exports = make(chan error, 1)
+
+	// timelineErr is the error the last step back or seek failed with, shown
+	// in the status line until one succeeds.
+	timelineErr error
+
+	// The pattern of the current generation is analyzed in the background while
+	// the game is still. analysisKey identifies the generation analyzed last.
+	analysisKey    analysisGen
+	analysisCancel context.CancelFunc
+	analysisStatus string
+	// analysisResults carries the results of the background analyses to the UI
+	// goroutine, which shows the one for analysisKey.
+	analysisResults = make(chan analysisResult, 1)
 
 	// Metrics
 	startTime  time.Time
@@ -54,6 +91,12 @@ var (
 	fps        float64
 )
 
+const historyLength = 4096
+
+// analysisGenerations is the number of generations a pattern is run for to see
+// whether it repeats.
+const analysisGenerations = 1000
+
 var (
 	explorerInstance *explorer.Explorer
 	once             sync.Once
@@ -66,7 +109,13 @@ func GetExplorerInstance(w *app.Window) *explorer.Explorer {
 	return explorerInstance
 }
 
+// stopPlayback stops playing and cancels the jump, waiting for it to return so
+// the game can be changed.
 func stopPlayback() {
+	if jumping() {
+		jumpCancel()
+		finishJump(true)
+	}
 	if playing {
 		if playStopCh != nil {
 			close(playStopCh)
@@ -76,3 +125,196 @@ func stopPlayback() {
 		paused = false
 	}
 }
+
+// startJump advances the game by 2^jumpExp generations in the background,
+// redrawing the window as it goes. The game stays as it is, without its history,
+// until finishJump swaps in the result.
+func startJump(w *app.Window) {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan game.Game, 1)
+	jumpCancel, jumpResult = cancel, result
+	jumpDone.Store(0)
+	jumpTotal = 1 << jumpExp
+
+	src := gameState.CurrentBoard()
+	g := game.Game{
+		BoardA:   src.Clone(),
+		BoardB:   src.Clone(),
+		UseA:     true,
+		Turn:     gameState.Turn,
+		Rule:     gameState.Rule,
+		Topology: gameState.Topology,
+		History:  gameState.History,
+	}
+	gameState.History = nil
+	go func() {
+		// A cancelled jump leaves the game at the last generation it finished,
+		// which is what the user asked for
+		_ = g.Advance(ctx, int(jumpTotal), func(done, total int) {
+			jumpDone.Store(int64(done))
+			w.Invalidate()
+		})
+		cancel()
+		result <- g
+		w.Invalidate()
+	}()
+}
+
+// finishJump swaps in the game of the last jump once it has returned, waiting
+// for it if wait is set.
+func finishJump(wait bool) {
+	if jumpResult == nil {
+		return
+	}
+	var g game.Game
+	if wait {
+		g = <-jumpResult
+	} else {
+		select {
+		case g = <-jumpResult:
+		default:
+			return
+		}
+	}
+	jumpResult = nil
+	gameState.Close()
+	gameState = g
+}
+
+// jumping reports whether a jump is running, including one that was cancelled
+// but hasn't been swapped in yet.
+func jumping() bool {
+	return jumpResult != nil
+}
+
+// jumpPercent returns how far the running jump has got, in percent.
+func jumpPercent() int {
+	return int(float64(jumpDone.Load()) * 100 / float64(jumpTotal))
+}
+
+// setExportErr records the outcome of an export, logging failures other than
+// the user closing the dialog.
+func setExportErr(err error) {
+	if err != nil && !errors.Is(err, explorer.ErrUserDecline) {
+		game.Logf("export: %v", err)
+	}
+	fileWriteErr = err
+}
+
+// setTimelineErr records the outcome of a step back or seek, logging failures.
+func setTimelineErr(err error) {
+	if err != nil {
+		game.Logf("timeline: %v", err)
+	}
+	timelineErr = err
+}
+
+// backendLabel names the backend computing the generations, with the auto
+// backend's current choice.
+func backendLabel() string {
+	name := game.BackendName()
+	if d := gameState.BackendDecision(); name == "auto" && d.Backend != "" {
+		return name + " (" + d.Backend + ")"
+	}
+	return name
+}
+
+// newGame starts a game from initialBoard under activeRule.
+func newGame() game.Game {
+	return gameAt(initialBoard, 1)
+}
+
+// gameAt returns a game under the active rule at generation turn, with a copy of b.
+func gameAt(b board.Board, turn int) game.Game {
+	return game.Game{
+		BoardA:  b.Clone(),
+		BoardB:  b.Clone(),
+		UseA:    true,
+		Turn:    turn,
+		Rule:    activeRule,
+		History: game.NewHistory(historyLength),
+	}
+}
+
+// timelineGen returns the generation at a position of the timeline, from 0 to 1,
+// which spans the generations in the game's history.
+func timelineGen(pos float32) int {
+	h := gameState.History
+	return h.Oldest() + int(math.Round(float64(pos)*float64(h.Newest()-h.Oldest())))
+}
+
+// timelinePos returns the position of the current generation on the timeline.
+func timelinePos() float32 {
+	h := gameState.History
+	if h.Newest() == h.Oldest() {
+		return 1
+	}
+	return float32(gameState.Turn-h.Oldest()) / float32(h.Newest()-h.Oldest())
+}
+
+// analysisGen identifies a generation by its turn and where and what its pattern is.
+type analysisGen struct {
+	turn     int
+	hash     uint64
+	row, col int
+}
+
+// analysisResult is what the analysis of a generation found.
+type analysisResult struct {
+	key    analysisGen
+	status string
+}
+
+// updateAnalysis shows the result of the background analysis once it comes in. It
+// starts analyzing the current generation if the game is still and it wasn't
+// analyzed yet, cancelling the analysis of the last one.
+func updateAnalysis(w *app.Window) {
+	for drained := false; !drained; {
+		select {
+		case res := <-analysisResults:
+			// Results of cancelled analyses may still come in
+			if res.key == analysisKey {
+				analysisStatus = res.status
+			}
+		default:
+			drained = true
+		}
+	}
+	if !canEdit() {
+		return
+	}
+	src := gameState.CurrentBoard()
+	h, row, col := analysis.Hash(src)
+	key := analysisGen{gameState.Turn, h, row, col}
+	if key == analysisKey {
+		return
+	}
+	analysisKey = key
+	if analysisCancel != nil {
+		analysisCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	analysisCancel = cancel
+	analysisStatus = "analyzing"
+
+	// Run a copy, so the game stays where it is
+	g := game.Game{
+		BoardA:   src.Clone(),
+		BoardB:   src.Clone(),
+		UseA:     true,
+		Turn:     gameState.Turn,
+		Rule:     gameState.Rule,
+		Topology: gameState.Topology,
+	}
+	go func() {
+		r, err := analysis.Run(ctx, &g, analysisGenerations)
+		if err != nil || ctx.Err() != nil {
+			return
+		}
+		select {
+		case analysisResults <- analysisResult{key, r.String()}:
+			w.Invalidate()
+		case <-ctx.Done():
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// gridSpan returns the x pixels of the left and right edges of a bounded grid's row.
+func gridSpan(lattice rule.Neighborhood, topo board.Topology, row, minCol, cellSize int) (x0, x1 int) {
+	_, left, _, right := topo.Extent()
+	cellWidth := cellSize
+	if lattice.IsTriangular() {
+		cellWidth = 2 * colPitch(lattice, cellSize)
+	}
+	return cellX(lattice, row, left, minCol, cellSize),
+		cellX(lattice, row, right, minCol, cellSize) + cellWidth
+}
+
+// shadeOutside fills the part of the view past the edges of a bounded grid.
+func shadeOutside(img *image.RGBA, col color.NRGBA, lattice rule.Neighborhood, topo board.Topology, minRow, minCol, maxRow, cellSize int) {
+	src := image.NewUniform(col)
+	top, _, bottom, _ := topo.Extent()
+	width := img.Bounds().Dx()
+	for row := minRow; row < maxRow; row++ {
+		y := (row - minRow) * cellSize
+		if row < top || row > bottom {
+			draw.Draw(img, image.Rect(0, y, width, y+cellSize), src, image.Point{}, draw.Src)
+			continue
+		}
+		x0, x1 := gridSpan(lattice, topo, row, minCol, cellSize)
+		draw.Draw(img, image.Rect(0, y, x0, y+cellSize), src, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x1, y, width, y+cellSize), src, image.Point{}, draw.Src)
+	}
+}
+
+// drawEdges outlines a bounded grid with lines two pixels wide.
+func drawEdges(img *image.RGBA, col color.NRGBA, lattice rule.Neighborhood, topo board.Topology, minRow, minCol, maxRow, cellSize int) {
+	src := image.NewUniform(col)
+	top, _, bottom, _ := topo.Extent()
+	for row := max(minRow, top); row < maxRow && row <= bottom; row++ {
+		y := (row - minRow) * cellSize
+		x0, x1 := gridSpan(lattice, topo, row, minCol, cellSize)
+		draw.Draw(img, image.Rect(x0-1, y, x0+1, y+cellSize), src, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x1-1, y, x1+1, y+cellSize), src, image.Point{}, draw.Src)
+		if row == top {
+			draw.Draw(img, image.Rect(x0-1, y-1, x1+1, y+1), src, image.Point{}, draw.Src)
+		}
+		if row == bottom {
+			draw.Draw(img, image.Rect(x0-1, y+cellSize-1, x1+1, y+cellSize+1), src, image.Point{}, draw.Src)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package gui
 
 import (
 	"fmt"
+	"image/color"
 	"time"
 
 	"gioui.org/app"
@@ -35,8 +36,10 @@ func runWindow(w *app.Window) error {
 		case app.FrameEvent:
 			gtx := app.NewContext(&ops, evt)
 
+			finishJump(false)
 			HandleEvents(gtx, &cache, w)
 			HandleControlClicks(gtx, &cache, w)
+			updateAnalysis(w)
 
 			layout.Flex{
 				Axis: layout.Vertical,
@@ -47,7 +50,20 @@ func runWindow(w *app.Window) error {
 					return layout.Center.Layout(gtx, label.Layout)
 				}),
 				layout.Rigid(func(gtx C) D {
-					label := material.Body1(th, fmt.Sprintf("Zoom: %.2fx  Pan: (%d,%d)", zoomLevel, panX, panY))
+					label := material.Body1(th, fmt.Sprintf("Rule: %s  Backend: %s  Zoom: %.2fx  Pan: (%d,%d)",
+						gameState.ActiveRule(), backendLabel(), zoomLevel, panX, panY))
+					return layout.Center.Layout(gtx, label.Layout)
+				}),
+				layout.Rigid(func(gtx C) D {
+					label := material.Body1(th, "Pattern: "+analysisStatus)
+					return layout.Center.Layout(gtx, label.Layout)
+				}),
+				layout.Rigid(func(gtx C) D {
+					if timelineErr == nil {
+						return D{}
+					}
+					label := material.Body1(th, "Timeline: "+timelineErr.Error())
+					label.Color = color.NRGBA{R: 200, A: 255}
 					return layout.Center.Layout(gtx, label.Layout)
 				}),
 				layout.Flexed(1, func(gtx C) D {
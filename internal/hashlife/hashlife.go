@@ -0,0 +1,322 @@
+// Package hashlife implements Bill Gosper's HashLife algorithm, which advances a
+// pattern by 2^k generations at once by memoizing the evolution of quadtree nodes.
+// Patterns with a lot of repetition in space and time, such as the Turing machine or
+// the prime calculator, can be run to generations far beyond the reach of a per-cell tick.
+package hashlife
+
+import (
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// DefaultMaxNodes is the node cache size above which a Universe collects garbage.
+const DefaultMaxNodes = 1 << 22
+
+// node is a square of 2^level cells. Nodes are immutable and hash-consed, so equal
+// squares share one node and a result computed for one is reused for all of them.
+type node struct {
+	nw, ne, sw, se *node
+	level          int
+	population     int
+
+	// result is the center half of the square advanced by 2^resultStep generations.
+	result     *node
+	resultStep int
+
+	// mark is the garbage collection epoch in which the node was last reached.
+	mark uint32
+}
+
+type key struct {
+	nw, ne, sw, se *node
+}
+
+// Universe is a pattern stored as a quadtree centered on the origin, together with
+// the cache of every node built so far.
+type Universe struct {
+	rule        *rule.Rule
+	root        *node
+	table       map[key]*node
+	dead, alive *node
+	empty       []*node // empty[level] is the empty square of that level
+	step        int
+	epoch       uint32
+
+	// MaxNodes bounds the node cache. When a step leaves more nodes than this,
+	// the nodes that are no longer part of the pattern are dropped.
+	MaxNodes int
+}
+
+// Supports reports whether HashLife can run a rule. It needs two-state rules with
+// the 8-cell neighborhood (which also covers hexagonal rules) on an unbounded grid.
+func Supports(r *rule.Rule) bool {
+	return r.LtL == nil && r.States == 2 && !r.Neighborhood.IsTriangular() && !r.Topology.Bounded()
+}
+
+// New returns an empty universe for a rule, which must be supported (see Supports).
+func New(r *rule.Rule) *Universe {
+	u := &Universe{
+		rule:     r,
+		table:    make(map[key]*node),
+		dead:     &node{},
+		alive:    &node{population: 1},
+		MaxNodes: DefaultMaxNodes,
+	}
+	u.empty = []*node{u.dead}
+	u.root = u.emptyNode(3)
+	return u
+}
+
+// Rule returns the rule the universe runs.
+func (u *Universe) Rule() *rule.Rule {
+	return u.rule
+}
+
+// Population returns the number of live cells.
+func (u *Universe) Population() int {
+	return u.root.population
+}
+
+// Nodes returns the number of nodes in the cache.
+func (u *Universe) Nodes() int {
+	return len(u.table)
+}
+
+// Load replaces the pattern with the live cells of a board. The node cache is kept,
+// so results computed for earlier patterns are reused.
+func (u *Universe) Load(g board.Board) {
+	cells := make([][2]int, 0, g.Len())
+	for pos, state := range g.All() {
+		if state.IsAlive() {
+			cells = append(cells, pos)
+		}
+	}
+	minRow, minCol, maxRow, maxCol := g.Bounds()
+	level := 3
+	for half := 1 << (level - 1); minRow < -half || minCol < -half || maxRow >= half || maxCol >= half; half <<= 1 {
+		level++
+	}
+	half := 1 << (level - 1)
+	u.root = u.build(level, -half, -half, cells)
+}
+
+// build returns the node of a level whose upper-left cell is (row, col), given the
+// live cells that lie inside it. It reorders cells.
+func (u *Universe) build(level, row, col int, cells [][2]int) *node {
+	if len(cells) == 0 {
+		return u.emptyNode(level)
+	}
+	if level == 0 {
+		return u.alive
+	}
+	half := 1 << (level - 1)
+	top := partition(cells, func(p [2]int) bool { return p[0] < row+half })
+	nw := partition(cells[:top], func(p [2]int) bool { return p[1] < col+half })
+	sw := top + partition(cells[top:], func(p [2]int) bool { return p[1] < col+half })
+	return u.join(
+		u.build(level-1, row, col, cells[:nw]),
+		u.build(level-1, row, col+half, cells[nw:top]),
+		u.build(level-1, row+half, col, cells[top:sw]),
+		u.build(level-1, row+half, col+half, cells[sw:]),
+	)
+}
+
+// partition moves the cells matching pred to the front and returns how many there are.
+func partition(cells [][2]int, pred func([2]int) bool) int {
+	n := 0
+	for i := range cells {
+		if pred(cells[i]) {
+			cells[i], cells[n] = cells[n], cells[i]
+			n++
+		}
+	}
+	return n
+}
+
+// Store replaces the contents of a board with the pattern.
+func (u *Universe) Store(g board.Board) {
+	g.Clear()
+	half := 1 << (u.root.level - 1)
+	collectCells(u.root, -half, -half, g)
+}
+
+func collectCells(n *node, row, col int, g board.Board) {
+	if n.population == 0 {
+		return
+	}
+	if n.level == 0 {
+		g.Set(row, col, board.Alive)
+		return
+	}
+	half := 1 << (n.level - 1)
+	collectCells(n.nw, row, col, g)
+	collectCells(n.ne, row, col+half, g)
+	collectCells(n.sw, row+half, col, g)
+	collectCells(n.se, row+half, col+half, g)
+}
+
+// Step advances the pattern by 2^k generations.
+func (u *Universe) Step(k int) {
+	// The result of a node is its center half, so the pattern must sit well inside
+	// the root for nothing to be lost as it grows by up to one cell per generation
+	for u.root.level < k+2 || !centered(u.root) {
+		u.expand()
+	}
+	u.expand()
+	u.step = k
+	u.root = u.result(u.root)
+
+	if len(u.table) > u.MaxNodes {
+		u.collectGarbage()
+	}
+}
+
+// centered reports whether a node's live cells all lie in its center half.
+func centered(n *node) bool {
+	return n.nw.nw.population+n.nw.ne.population+n.nw.sw.population == 0 &&
+		n.ne.nw.population+n.ne.ne.population+n.ne.se.population == 0 &&
+		n.sw.nw.population+n.sw.sw.population+n.sw.se.population == 0 &&
+		n.se.ne.population+n.se.sw.population+n.se.se.population == 0
+}
+
+// expand doubles the size of the root, keeping the pattern centered.
+func (u *Universe) expand() {
+	r := u.root
+	e := u.emptyNode(r.level - 1)
+	u.root = u.join(
+		u.join(e, e, e, r.nw),
+		u.join(e, e, r.ne, e),
+		u.join(e, r.sw, e, e),
+		u.join(r.se, e, e, e),
+	)
+}
+
+func (u *Universe) emptyNode(level int) *node {
+	for len(u.empty) <= level {
+		e := u.empty[len(u.empty)-1]
+		u.empty = append(u.empty, u.join(e, e, e, e))
+	}
+	return u.empty[level]
+}
+
+// join returns the canonical node with the given quadrants.
+func (u *Universe) join(nw, ne, sw, se *node) *node {
+	k := key{nw, ne, sw, se}
+	if n, ok := u.table[k]; ok {
+		return n
+	}
+	n := &node{
+		nw: nw, ne: ne, sw: sw, se: se,
+		level:      nw.level + 1,
+		population: nw.population + ne.population + sw.population + se.population,
+	}
+	u.table[k] = n
+	return n
+}
+
+// center returns the center half of a node.
+func (u *Universe) center(n *node) *node {
+	return u.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// result returns the center half of a node of level 2 or more, advanced by
+// 2^min(step, level-2) generations.
+func (u *Universe) result(n *node) *node {
+	step := min(u.step, n.level-2)
+	if n.result != nil && n.resultStep == step {
+		return n.result
+	}
+
+	var res *node
+	switch {
+	case n.population == 0:
+		res = u.emptyNode(n.level - 1)
+	case n.level == 2:
+		res = u.base(n)
+	default:
+		// Nine overlapping squares of half the size, from the upper-left
+		squares := [9]*node{
+			n.nw, u.join(n.nw.ne, n.ne.nw, n.nw.se, n.ne.sw), n.ne,
+			u.join(n.nw.sw, n.nw.se, n.sw.nw, n.sw.ne), u.center(n), u.join(n.ne.sw, n.ne.se, n.se.nw, n.se.ne),
+			n.sw, u.join(n.sw.ne, n.se.nw, n.sw.se, n.se.sw), n.se,
+		}
+		// At full speed both halves of the step advance 2^(level-3) generations;
+		// for smaller steps the first half only takes the centers
+		var a [9]*node
+		for i, s := range squares {
+			if step == n.level-2 {
+				a[i] = u.result(s)
+			} else {
+				a[i] = u.center(s)
+			}
+		}
+		res = u.join(
+			u.result(u.join(a[0], a[1], a[3], a[4])),
+			u.result(u.join(a[1], a[2], a[4], a[5])),
+			u.result(u.join(a[3], a[4], a[6], a[7])),
+			u.result(u.join(a[4], a[5], a[7], a[8])),
+		)
+	}
+	n.result, n.resultStep = res, step
+	return res
+}
+
+// base advances the center 2x2 cells of a 4x4 node by one generation.
+func (u *Universe) base(n *node) *node {
+	var cells [4][4]bool
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			q := [2][2]*node{{n.nw, n.ne}, {n.sw, n.se}}[r/2][c/2]
+			leaf := [2][2]*node{{q.nw, q.ne}, {q.sw, q.se}}[r%2][c%2]
+			cells[r][c] = leaf.population != 0
+		}
+	}
+
+	next := func(r, c int) *node {
+		var config uint8
+		for i, d := range rule.Neighbors {
+			if cells[r+d[0]][c+d[1]] {
+				config |= 1 << i
+			}
+		}
+		state := board.Dead
+		if cells[r][c] {
+			state = board.Alive
+		}
+		if u.rule.NextConfig(state, config) == board.Alive {
+			return u.alive
+		}
+		return u.dead
+	}
+	return u.join(next(1, 1), next(1, 2), next(2, 1), next(2, 2))
+}
+
+// collectGarbage drops the nodes that are not part of the pattern or an empty square,
+// and forgets results that point to dropped nodes.
+func (u *Universe) collectGarbage() {
+	u.epoch++
+	u.markNodes(u.root)
+	u.markNodes(u.empty[len(u.empty)-1])
+	for k, n := range u.table {
+		if n.mark != u.epoch {
+			delete(u.table, k)
+			continue
+		}
+		if n.result != nil && n.result.mark != u.epoch {
+			n.result = nil
+		}
+	}
+}
+
+func (u *Universe) markNodes(n *node) {
+	if n.mark == u.epoch {
+		return
+	}
+	n.mark = u.epoch
+	if n.level > 0 {
+		u.markNodes(n.nw)
+		u.markNodes(n.ne)
+		u.markNodes(n.sw)
+		u.markNodes(n.se)
+	}
+}
@@ -0,0 +1,237 @@
+package rule
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Neighbors lists the Moore neighborhood offsets as {dRow, dCol} in row-major order.
+// A neighborhood configuration is a byte whose bit i is set when neighbor i is alive,
+// so the neighbor opposite bit i is bit 7-i.
+var Neighbors = [8][2]int{
+	{-1, -1}, {-1, 0}, {-1, 1},
+	{0, -1}, {0, 1},
+	{1, -1}, {1, 0}, {1, 1},
+}
+
+// henselLetterOrder is the canonical order of the Hensel letters.
+const henselLetterOrder = "cekainyqjrtwz"
+
+// henselReps holds one representative neighborhood per count and Hensel letter, listed
+// clockwise from north as N, NE, E, SE, S, SW, W, NW. Counts 5-8 are the complements of
+// the counts 3-0 with the same letter.
+var henselReps = [5]map[byte]string{
+	0: {},
+	1: {'e': "10000000", 'c': "01000000"},
+	2: {
+		'a': "11000000", 'e': "10100000", 'k': "10010000",
+		'i': "10001000", 'c': "01010000", 'n': "01000100",
+	},
+	3: {
+		'a': "11100000", 'n': "11010000", 'r': "11001000", 'q': "11000100",
+		'j': "11000010", 'i': "11000001", 'e': "10101000", 'k': "10100100",
+		'y': "10010100", 'c': "01010100",
+	},
+	4: {
+		'a': "11110000", 'r': "11101000", 'q': "11100100", 'i': "11011000",
+		'y': "11010100", 'k': "11010010", 'n': "11010001", 'z': "11001100",
+		'j': "11001010", 't': "10011100", 'w': "11000110", 'e': "10101010",
+		'c': "01010101",
+	},
+}
+
+// ringBits maps the clockwise ring positions used by henselReps to configuration bits.
+var ringBits = [8]uint{1, 2, 4, 7, 6, 5, 3, 0}
+
+// configSet is a set of neighborhood configurations.
+type configSet [256]bool
+
+// henselLetters returns the letters that are valid for a neighbor count.
+func henselLetters(count int) string {
+	if count > 4 {
+		count = 8 - count
+	}
+	var sb strings.Builder
+	for i := 0; i < len(henselLetterOrder); i++ {
+		if _, ok := henselReps[count][henselLetterOrder[i]]; ok {
+			sb.WriteByte(henselLetterOrder[i])
+		}
+	}
+	return sb.String()
+}
+
+// henselConfig returns a representative configuration for a count and letter.
+// A letter of 0 is used for counts 0 and 8, which have a single configuration.
+func henselConfig(count int, letter byte) uint8 {
+	switch count {
+	case 0:
+		return 0
+	case 8:
+		return 0xff
+	}
+	if count > 4 {
+		return ^henselConfig(8-count, letter)
+	}
+	var config uint8
+	for i, ch := range henselReps[count][letter] {
+		if ch == '1' {
+			config |= 1 << ringBits[i]
+		}
+	}
+	return config
+}
+
+// symmetries returns the configurations equivalent to config under rotation and reflection.
+func symmetries(config uint8) [8]uint8 {
+	var ring [8]bool
+	for i := range ring {
+		ring[i] = config&(1<<ringBits[i]) != 0
+	}
+	var out [8]uint8
+	for t := 0; t < 8; t++ {
+		var c uint8
+		for i := 0; i < 8; i++ {
+			// Rotate by quarter turns, then optionally mirror across the north-south axis
+			j := (i + 2*(t%4)) % 8
+			if t >= 4 {
+				j = (8 - j) % 8
+			}
+			if ring[i] {
+				c |= 1 << ringBits[j]
+			}
+		}
+		out[t] = c
+	}
+	return out
+}
+
+// addHensel adds every configuration matching a count and letter to the set.
+func (s *configSet) addHensel(count int, letter byte) {
+	for _, c := range symmetries(henselConfig(count, letter)) {
+		s[c] = true
+	}
+}
+
+// addCount adds every configuration with the given number of live neighbors to the set.
+func (s *configSet) addCount(count int) {
+	for c := 0; c < 256; c++ {
+		if bits.OnesCount8(uint8(c)) == count {
+			s[c] = true
+		}
+	}
+}
+
+// hasHensel reports whether the set contains the configurations of a count and letter.
+func (s *configSet) hasHensel(count int, letter byte) bool {
+	return s[henselConfig(count, letter)]
+}
+
+// parseConditions parses a birth or survival condition made of neighbor counts, each
+// optionally followed by Hensel letters ("34q") or excluded letters ("2-a").
+func parseConditions(s string) (configSet, error) {
+	var set configSet
+	for i := 0; i < len(s); {
+		ch := s[i]
+		if ch < '0' || ch > '8' {
+			return set, fmt.Errorf("neighbor counts must be digits 0-8, got %q", ch)
+		}
+		count := int(ch - '0')
+		i++
+
+		negate := false
+		if i < len(s) && s[i] == '-' {
+			negate = true
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] >= 'a' && s[i] <= 'z' {
+			i++
+		}
+		letters := s[start:i]
+		if negate && letters == "" {
+			return set, fmt.Errorf("expected Hensel letters after '%d-'", count)
+		}
+		valid := henselLetters(count)
+		for j := 0; j < len(letters); j++ {
+			if strings.IndexByte(valid, letters[j]) < 0 {
+				return set, fmt.Errorf("invalid Hensel letter %q for %d neighbors", letters[j], count)
+			}
+		}
+
+		if letters == "" {
+			set.addCount(count)
+			continue
+		}
+		for j := 0; j < len(valid); j++ {
+			if (strings.IndexByte(letters, valid[j]) >= 0) != negate {
+				set.addHensel(count, valid[j])
+			}
+		}
+	}
+	return set, nil
+}
+
+// totalistic returns the neighbor count mask equivalent to the set, and whether the set
+// depends only on the neighbor count.
+func (s *configSet) totalistic() (uint16, bool) {
+	var full, any [9]bool
+	for i := range full {
+		full[i] = true
+	}
+	for c := 0; c < 256; c++ {
+		n := bits.OnesCount8(uint8(c))
+		if s[c] {
+			any[n] = true
+		} else {
+			full[n] = false
+		}
+	}
+	var mask uint16
+	for n := 0; n <= 8; n++ {
+		if any[n] != full[n] {
+			return 0, false
+		}
+		if full[n] {
+			mask |= 1 << n
+		}
+	}
+	return mask, true
+}
+
+// format writes the set in Hensel notation, choosing for each count whichever of the
+// included or excluded letters is shorter.
+func (s *configSet) format() string {
+	var sb strings.Builder
+	for n := 0; n <= 8; n++ {
+		valid := henselLetters(n)
+		if valid == "" {
+			if s.hasHensel(n, 0) {
+				sb.WriteByte(byte('0' + n))
+			}
+			continue
+		}
+		var in, out strings.Builder
+		for i := 0; i < len(valid); i++ {
+			if s.hasHensel(n, valid[i]) {
+				in.WriteByte(valid[i])
+			} else {
+				out.WriteByte(valid[i])
+			}
+		}
+		switch {
+		case in.Len() == 0:
+			continue
+		case out.Len() == 0:
+			sb.WriteByte(byte('0' + n))
+		case out.Len() < in.Len():
+			sb.WriteByte(byte('0' + n))
+			sb.WriteByte('-')
+			sb.WriteString(out.String())
+		default:
+			sb.WriteByte(byte('0' + n))
+			sb.WriteString(in.String())
+		}
+	}
+	return sb.String()
+}
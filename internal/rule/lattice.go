@@ -0,0 +1,164 @@
+package rule
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// latticeSuffixes are the rulestring suffixes of the non-Moore B/S neighborhoods.
+var latticeSuffixes = map[Neighborhood]string{
+	Hexagonal:          "H",
+	Triangular:         "L",
+	TriangularEdges:    "LE",
+	TriangularVertices: "LV",
+}
+
+// hexMask selects the neighborhood configuration bits of the hexagonal neighbors,
+// i.e. every Moore neighbor except NE (bit 2) and SW (bit 5).
+const hexMask uint8 = 0b11011011
+
+// triangleNeighbors holds the neighbor offsets of an upward-pointing triangle;
+// a downward-pointing one uses the same offsets mirrored vertically.
+var (
+	triangleEdges    = [][2]int{{0, -1}, {0, 1}, {1, 0}}
+	triangleVertices = [][2]int{
+		{-1, -1}, {-1, 0}, {-1, 1},
+		{0, -2}, {0, 2},
+		{1, -2}, {1, -1}, {1, 1}, {1, 2},
+	}
+)
+
+// IsTriangular reports whether the neighborhood is one of the triangular grid neighborhoods.
+func (n Neighborhood) IsTriangular() bool {
+	return n == Triangular || n == TriangularEdges || n == TriangularVertices
+}
+
+// Size returns the number of neighbors in a range-1 neighborhood.
+func (n Neighborhood) Size() int {
+	switch n {
+	case VonNeumann:
+		return 4
+	case Hexagonal:
+		return 6
+	case Triangular:
+		return len(triangleEdges) + len(triangleVertices)
+	case TriangularEdges:
+		return len(triangleEdges)
+	case TriangularVertices:
+		return len(triangleVertices)
+	}
+	return 8
+}
+
+// ConfigMask returns the neighborhood configuration bits that a range-1 square-grid
+// neighborhood counts: all eight Moore neighbors, or the six of the hexagonal one.
+// It is zero for the triangular neighborhoods, which don't use the Moore offsets.
+func (n Neighborhood) ConfigMask() uint8 {
+	switch n {
+	case Moore:
+		return 0xff
+	case Hexagonal:
+		return hexMask
+	}
+	return 0
+}
+
+// PointsUp reports whether the triangle at (row, col) of a triangular grid points up.
+func PointsUp(row, col int) bool {
+	return (row+col)%2 == 0
+}
+
+// TriangleNeighbors returns the neighbor offsets {dRow, dCol} of the triangle at
+// (row, col) for a triangular neighborhood. The neighbor relation is symmetric.
+func TriangleNeighbors(n Neighborhood, row, col int) [][2]int {
+	var offsets [][2]int
+	switch n {
+	case Triangular:
+		offsets = append(append(offsets, triangleEdges...), triangleVertices...)
+	case TriangularEdges:
+		offsets = append(offsets, triangleEdges...)
+	case TriangularVertices:
+		offsets = append(offsets, triangleVertices...)
+	}
+	if !PointsUp(row, col) {
+		for i := range offsets {
+			offsets[i][0] = -offsets[i][0]
+		}
+	}
+	return offsets
+}
+
+// cutLatticeSuffix removes a hexagonal or triangular suffix from a rulestring.
+func cutLatticeSuffix(s string) (string, Neighborhood) {
+	upper := strings.ToUpper(s)
+	// Check the two-letter suffixes before "L"
+	for _, n := range []Neighborhood{TriangularEdges, TriangularVertices, Triangular, Hexagonal} {
+		if suffix := latticeSuffixes[n]; strings.HasSuffix(upper, suffix) {
+			return s[:len(s)-len(suffix)], n
+		}
+	}
+	return s, Moore
+}
+
+// parseLatticeCounts parses the birth and survival counts of a hexagonal or triangular
+// rule and builds its transition table. These lattices are totalistic only.
+func (r *Rule) parseLatticeCounts(birthPart, survivePart string) error {
+	var err error
+	if r.Birth, err = parseCounts(birthPart, r.Neighborhood.Size()); err != nil {
+		return err
+	}
+	if r.Survive, err = parseCounts(survivePart, r.Neighborhood.Size()); err != nil {
+		return err
+	}
+	if r.Birth&1 != 0 {
+		return fmt.Errorf("B0 rules are not supported on an infinite grid")
+	}
+	if r.Neighborhood == Hexagonal {
+		// Hexagonal rules reuse the Moore transition table, ignoring the NE and SW neighbors
+		for c := 0; c < 256; c++ {
+			n := bits.OnesCount8(uint8(c) & hexMask)
+			r.birth[c] = r.Birth&(1<<n) != 0
+			r.survive[c] = r.Survive&(1<<n) != 0
+		}
+	}
+	return nil
+}
+
+// parseCounts parses a list of single-character neighbor counts, using a, b and c for 10-12.
+func parseCounts(s string, maxCount int) (uint16, error) {
+	var mask uint16
+	for _, ch := range strings.ToLower(s) {
+		n := -1
+		switch {
+		case ch >= '0' && ch <= '9':
+			n = int(ch - '0')
+		case ch >= 'a' && ch <= 'c':
+			n = int(ch-'a') + 10
+		}
+		if n < 0 || n > maxCount {
+			return 0, fmt.Errorf("neighbor counts must be from 0 to %d, got %q", maxCount, ch)
+		}
+		mask |= 1 << n
+	}
+	return mask, nil
+}
+
+// checkLatticeTopology rejects bounded grids that would join up- and down-pointing
+// triangles. Only planes and tori with even sizes and shifts keep the triangles
+// consistent across their edges.
+func (r *Rule) checkLatticeTopology() error {
+	t := r.Topology
+	if !r.Neighborhood.IsTriangular() || t.Kind == board.Plane {
+		return nil
+	}
+	if t.Kind != board.Torus {
+		return fmt.Errorf("triangular rules only support plane and torus grids")
+	}
+	if t.Width%2 != 0 || t.Height%2 != 0 || t.ShiftCol%2 != 0 || t.ShiftRow%2 != 0 {
+		return fmt.Errorf("triangular rules need a torus with even sizes and shifts")
+	}
+	return nil
+}
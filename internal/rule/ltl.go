@@ -0,0 +1,126 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxRange is the largest neighborhood radius of a Larger than Life rule.
+const MaxRange = 500
+
+// LtL describes a Larger than Life rule, where births and survivals depend on the
+// number of live cells within Range of a cell lying in an inclusive interval.
+type LtL struct {
+	Range int
+
+	// Middle is set if a cell counts itself as one of its neighbors (M1).
+	Middle bool
+
+	BirthMin, BirthMax     int
+	SurviveMin, SurviveMax int
+}
+
+// isLtL reports whether s looks like a Larger than Life rulestring, e.g. "R5,C0,...".
+func isLtL(s string) bool {
+	return len(s) > 1 && (s[0] == 'R' || s[0] == 'r') && s[1] >= '0' && s[1] <= '9'
+}
+
+// parseLtL parses a rule in Golly's Larger than Life notation: "Rr,Cc,Mm,Smin..max,Bmin..max,Nn".
+// C0 and C1 mean two states, larger values give Generations-style dying states.
+// M0 and M1 exclude or include the cell itself in its count, and N is NM (Moore) or NN (von Neumann).
+func parseLtL(s string) (*Rule, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid rule %q: expected Rr,Cc,Mm,Smin..max,Bmin..max,Nn", s)
+	}
+	ltl := &LtL{}
+	r := &Rule{States: 2, LtL: ltl}
+	var err error
+	for i, prefix := range []string{"R", "C", "M", "S", "B", "N"} {
+		f := fields[i]
+		if !hasPrefixFold(f, prefix) {
+			return nil, fmt.Errorf("invalid rule %q: field %d should start with %q", s, i+1, prefix)
+		}
+		v := f[1:]
+		switch prefix {
+		case "R":
+			ltl.Range, err = strconv.Atoi(v)
+			if err == nil && (ltl.Range < 1 || ltl.Range > MaxRange) {
+				err = fmt.Errorf("range must be from 1 to %d", MaxRange)
+			}
+		case "C":
+			var states int
+			states, err = strconv.Atoi(v)
+			if err == nil && (states < 0 || states > MaxStates) {
+				err = fmt.Errorf("state count must be from 0 to %d", MaxStates)
+			}
+			r.States = max(states, 2)
+		case "M":
+			switch v {
+			case "0":
+			case "1":
+				ltl.Middle = true
+			default:
+				err = fmt.Errorf("middle must be M0 or M1")
+			}
+		case "S":
+			ltl.SurviveMin, ltl.SurviveMax, err = parseInterval(v)
+		case "B":
+			ltl.BirthMin, ltl.BirthMax, err = parseInterval(v)
+		case "N":
+			switch strings.ToUpper(v) {
+			case "M":
+				r.Neighborhood = Moore
+			case "N":
+				r.Neighborhood = VonNeumann
+			default:
+				err = fmt.Errorf("neighborhood must be NM or NN")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+		}
+	}
+	if ltl.BirthMin == 0 {
+		return nil, fmt.Errorf("invalid rule %q: B0 rules are not supported on an infinite grid", s)
+	}
+	return r, nil
+}
+
+// parseInterval parses "min..max" or a single count.
+func parseInterval(s string) (lo, hi int, err error) {
+	loStr, hiStr, found := strings.Cut(s, "..")
+	if !found {
+		hiStr = loStr
+	}
+	if lo, err = strconv.Atoi(loStr); err != nil {
+		return 0, 0, fmt.Errorf("invalid count %q", loStr)
+	}
+	if hi, err = strconv.Atoi(hiStr); err != nil {
+		return 0, 0, fmt.Errorf("invalid count %q", hiStr)
+	}
+	if lo < 0 || hi < lo {
+		return 0, 0, fmt.Errorf("invalid interval %q", s)
+	}
+	return lo, hi, nil
+}
+
+func (r *Rule) formatLtL() string {
+	states := 0
+	if r.States > 2 {
+		states = r.States
+	}
+	middle := 0
+	if r.LtL.Middle {
+		middle = 1
+	}
+	n := "M"
+	if r.Neighborhood == VonNeumann {
+		n = "N"
+	}
+	return fmt.Sprintf("R%d,C%d,M%d,S%d..%d,B%d..%d,N%s",
+		r.LtL.Range, states, middle,
+		r.LtL.SurviveMin, r.LtL.SurviveMax,
+		r.LtL.BirthMin, r.LtL.BirthMax, n)
+}
@@ -0,0 +1,275 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kvitebjorn/gol/internal/board"
+)
+
+// MaxStates is the largest number of cell states a Generations rule may have.
+const MaxStates = 256
+
+// Neighborhood is the shape of the set of cells that count as a cell's neighbors.
+type Neighborhood int
+
+const (
+	// Moore is the square neighborhood: the 8 surrounding cells, or the
+	// (2R+1)x(2R+1) square for Larger than Life rules.
+	Moore Neighborhood = iota
+	// VonNeumann is the diamond of cells within Manhattan distance R.
+	VonNeumann
+	// Hexagonal is the 6-cell neighborhood of a hexagonal grid, emulated on the
+	// square grid by ignoring the NE and SW neighbors, as Golly does.
+	Hexagonal
+	// Triangular is the 12-cell neighborhood of a triangular grid, where cell (r, c)
+	// points up when r+c is even and down otherwise.
+	Triangular
+	// TriangularEdges is the 3 triangles sharing an edge with a cell.
+	TriangularEdges
+	// TriangularVertices is the 9 triangles sharing only a vertex with a cell.
+	TriangularVertices
+)
+
+// Rule describes a Life-like cellular automaton.
+// Birth and Survive are bitmasks indexed by live neighbor count, e.g. bit 3 of
+// Birth set means a dead cell with exactly 3 live neighbors is born.
+// Rules should be created with Parse, which also builds the transition table.
+type Rule struct {
+	Birth   uint16
+	Survive uint16
+
+	// States is the number of cell states. Plain Life-like rules have 2 (dead and alive);
+	// Generations rules have more, where a live cell that fails to survive passes
+	// through the dying states 2..States-1 before becoming dead.
+	States int
+
+	// Isotropic is set for isotropic non-totalistic rules (Hensel notation), where
+	// births and survivals depend on the arrangement of the live neighbors and not
+	// just their count. Birth and Survive are zero for these rules.
+	Isotropic bool
+
+	// Neighborhood is the shape of the neighborhood. Only Larger than Life rules
+	// may use VonNeumann, and only B/S rules may use the hexagonal and triangular ones.
+	Neighborhood Neighborhood
+
+	// LtL holds the parameters of a Larger than Life rule, or nil for the rules above.
+	LtL *LtL
+
+	// Topology is the bounded grid given by a rulestring suffix such as ":T100,80",
+	// or the zero Topology for an unbounded grid.
+	Topology board.Topology
+
+	birth, survive configSet
+}
+
+// Conway is the classic Game of Life rule, B3/S23.
+var Conway = MustParse("B3/S23")
+
+// Parse parses a rulestring in B/S notation.
+// Accepted forms are "B36/S23", "b3/s23", "S23/B3" and the older "23/3" (survive/birth) form.
+// Generations rules add a state count, as in "B2/S/C3" or the older "/2/3" form.
+// Counts in the B and S parts may be qualified with Hensel letters, as in "B2-a/S12".
+// Larger than Life rules use Golly's notation, as in "R5,C0,M1,S34..58,B34..45,NM".
+// A suffix selects another lattice: "H" for hexagonal, as in "B2/S34H", and "L", "LE"
+// or "LV" for the triangular neighborhoods, whose counts above 9 are written as a, b and c.
+// Any rule may end with a bounded grid, as in "B3/S23:T100,80" (see board.ParseTopology).
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	rs, topology, bounded := strings.Cut(s, ":")
+	r, err := parse(rs)
+	if err != nil || !bounded {
+		return r, err
+	}
+	if r.Topology, err = board.ParseTopology(topology); err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+	}
+	if err := r.checkLatticeTopology(); err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+	}
+	return r, nil
+}
+
+func parse(s string) (*Rule, error) {
+	if isLtL(s) {
+		return parseLtL(s)
+	}
+	r := &Rule{States: 2}
+	rs, neighborhood := cutLatticeSuffix(s)
+	r.Neighborhood = neighborhood
+	parts := strings.Split(rs, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid rule %q: expected two or three parts separated by '/'", s)
+	}
+
+	if len(parts) == 3 {
+		states, err := parseStates(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+		}
+		r.States = states
+	}
+	birthPart, survivePart := "", ""
+	first, second := parts[0], parts[1]
+	switch {
+	case hasPrefixFold(first, "b") && hasPrefixFold(second, "s"):
+		birthPart, survivePart = first[1:], second[1:]
+	case hasPrefixFold(first, "s") && hasPrefixFold(second, "b"):
+		birthPart, survivePart = second[1:], first[1:]
+	case isDigits(first) && isDigits(second):
+		// Old-style notation lists survival counts first
+		birthPart, survivePart = second, first
+	default:
+		return nil, fmt.Errorf("invalid rule %q", s)
+	}
+
+	if r.Neighborhood != Moore {
+		if err := r.parseLatticeCounts(birthPart, survivePart); err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+		}
+		return r, nil
+	}
+
+	var err error
+	if r.birth, err = parseConditions(birthPart); err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+	}
+	if r.survive, err = parseConditions(survivePart); err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+	}
+	if r.birth[0] {
+		return nil, fmt.Errorf("invalid rule %q: B0 rules are not supported on an infinite grid", s)
+	}
+
+	birth, birthOk := r.birth.totalistic()
+	survive, surviveOk := r.survive.totalistic()
+	if birthOk && surviveOk {
+		r.Birth, r.Survive = birth, survive
+	} else {
+		r.Isotropic = true
+	}
+	return r, nil
+}
+
+// MustParse is like Parse but panics if the rulestring is invalid.
+func MustParse(s string) *Rule {
+	r, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Next returns the state of a cell in the next generation given its current
+// state and its number of live neighbors. It is only meaningful for totalistic
+// and Larger than Life rules; use NextConfig for rules that are Isotropic.
+func (r *Rule) Next(state board.Cell, count int) board.Cell {
+	if r.LtL != nil {
+		return r.next(state,
+			count >= r.LtL.BirthMin && count <= r.LtL.BirthMax,
+			count >= r.LtL.SurviveMin && count <= r.LtL.SurviveMax)
+	}
+	return r.next(state, r.Birth&(1<<count) != 0, r.Survive&(1<<count) != 0)
+}
+
+// NextConfig returns the state of a cell in the next generation given its current
+// state and its neighborhood configuration (see Neighbors).
+func (r *Rule) NextConfig(state board.Cell, config uint8) board.Cell {
+	return r.next(state, r.birth[config], r.survive[config])
+}
+
+func (r *Rule) next(state board.Cell, born, survives bool) board.Cell {
+	switch state {
+	case board.Dead:
+		if born {
+			return board.Alive
+		}
+		return board.Dead
+	case board.Alive:
+		if survives {
+			return board.Alive
+		}
+	}
+	// Cells that fail to survive, and cells already dying, age by one state
+	if int(state)+1 >= r.States {
+		return board.Dead
+	}
+	return state + 1
+}
+
+// Table returns the birth and survival transitions indexed by alive<<8 | configuration,
+// with 1 where the cell is alive in the next generation. It is the form the GPU kernel uses.
+func (r *Rule) Table() [512]uint8 {
+	var t [512]uint8
+	for c := 0; c < 256; c++ {
+		if r.birth[c] {
+			t[c] = 1
+		}
+		if r.survive[c] {
+			t[256+c] = 1
+		}
+	}
+	return t
+}
+
+// String returns the canonical form of the rule, e.g. "B36/S23", "B2-a/S12", "B2/S/C3"
+// or "B3/S23:T100,80".
+func (r *Rule) String() string {
+	var s string
+	if r.Topology.Bounded() {
+		s = ":" + r.Topology.String()
+	}
+	return r.format() + s
+}
+
+func (r *Rule) format() string {
+	if r.LtL != nil {
+		return r.formatLtL()
+	}
+	var s string
+	if r.Isotropic {
+		s = "B" + r.birth.format() + "/S" + r.survive.format()
+	} else {
+		s = "B" + formatCounts(r.Birth) + "/S" + formatCounts(r.Survive)
+	}
+	if r.States > 2 {
+		s += "/C" + strconv.Itoa(r.States)
+	}
+	return s + latticeSuffixes[r.Neighborhood]
+}
+
+func parseStates(s string) (int, error) {
+	if hasPrefixFold(s, "c") || hasPrefixFold(s, "g") {
+		s = s[1:]
+	}
+	states, err := strconv.Atoi(s)
+	if err != nil || states < 2 || states > MaxStates {
+		return 0, fmt.Errorf("state count must be a number from 2 to %d", MaxStates)
+	}
+	return states, nil
+}
+
+func formatCounts(mask uint16) string {
+	const digits = "0123456789abc"
+	var sb strings.Builder
+	for n := 0; n < len(digits); n++ {
+		if mask&(1<<n) != 0 {
+			sb.WriteByte(digits[n])
+		}
+	}
+	return sb.String()
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isDigits(s string) bool {
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
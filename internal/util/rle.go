@@ -10,15 +10,19 @@ import (
 	"strings"
 
 	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
 )
 
-// ImportRLE parses an RLE file and returns an InfiniteGrid with the pattern.
-func ImportRLE(r io.Reader) (board.InfiniteGrid, error) {
+// ImportRLE parses an RLE file and returns a board with the pattern, along with the rule
+// from the header. The board is of the default implementation (see board.NewDefault).
+// The rule is nil if the header has no `rule =` field.
+func ImportRLE(r io.Reader) (board.Board, *rule.Rule, error) {
 	scanner := bufio.NewScanner(r)
 	var header string
 	var rows, cols int
+	var rl *rule.Rule
 	var dataLines []string
-	headerRe := regexp.MustCompile(`x *= *(\d+), *y *= *(\d+)`)
+	headerRe := regexp.MustCompile(`x *= *(\d+), *y *= *(\d+)(?:, *rule *= *(\S+))?`)
 	for scanner.Scan() {
 		line := scanner.Text()
 		line = strings.TrimSpace(line)
@@ -29,21 +33,30 @@ func ImportRLE(r io.Reader) (board.InfiniteGrid, error) {
 			header = line
 			m := headerRe.FindStringSubmatch(header)
 			if m == nil {
-				return board.InfiniteGrid{}, errors.New("invalid RLE header")
+				return nil, nil, errors.New("invalid RLE header")
 			}
 			cols, _ = strconv.Atoi(m[1])
 			rows, _ = strconv.Atoi(m[2])
+			if m[3] != "" {
+				var err error
+				rl, err = rule.Parse(m[3])
+				if err != nil {
+					return nil, nil, err
+				}
+			}
 			continue
 		}
 		dataLines = append(dataLines, line)
 	}
 	if rows == 0 || cols == 0 {
-		return board.InfiniteGrid{}, errors.New("missing RLE header")
+		return nil, nil, errors.New("missing RLE header")
 	}
-	ig := board.NewInfiniteGrid()
+	ig := board.NewDefault()
 	x, y := 0, 0
 	rle := strings.Join(dataLines, "")
 	num := 0
+	// Multi-state RLE encodes states above 24 with a prefix letter 'p'..'y'
+	prefix := 0
 	// Always place the pattern at (0,0)
 parseLoop:
 	for i := 0; i < len(rle); i++ {
@@ -51,18 +64,31 @@ parseLoop:
 		switch {
 		case c >= '0' && c <= '9':
 			num = num*10 + int(c-'0')
-		case c == 'b' || c == 'o':
+		case c == 'b' || c == 'o' || c == '.' || (c >= 'A' && c <= 'X'):
+			state := 0
+			switch {
+			case c == 'o':
+				state = 1
+			case c >= 'A' && c <= 'X':
+				state = prefix*24 + int(c-'A') + 1
+			}
+			if state >= rule.MaxStates {
+				return nil, nil, fmt.Errorf("invalid RLE cell state %d", state)
+			}
 			n := num
 			if n == 0 {
 				n = 1
 			}
 			for j := 0; j < n; j++ {
-				if c == 'o' {
-					ig.Set(y, x, true)
+				if state != 0 {
+					ig.Set(y, x, board.Cell(state))
 				}
 				x++
 			}
 			num = 0
+			prefix = 0
+		case c >= 'p' && c <= 'y':
+			prefix = int(c-'p') + 1
 		case c == '$':
 			n := num
 			if n == 0 {
@@ -75,54 +101,56 @@ parseLoop:
 			break parseLoop
 		}
 	}
-	return ig, nil
+	return ig, rl, nil
 }
 
-// ExportRLE writes the InfiniteGrid as an RLE pattern to the writer.
+// ExportRLE writes the board as an RLE pattern to the writer.
 // The exported region is the bounding box of all live cells.
-func ExportRLE(w io.Writer, g board.InfiniteGrid) error {
+// If r is non-nil, it is written to the header's `rule =` field, and patterns
+// for rules with more than two states use the multi-state cell encoding.
+func ExportRLE(w io.Writer, g board.Board, r *rule.Rule) error {
 	minRow, minCol, maxRow, maxCol := g.Bounds()
 	rows := maxRow - minRow + 1
 	cols := maxCol - minCol + 1
+	multiState := r != nil && r.States > 2
 	// Export the bounding box region, shifted to (0,0) in the RLE output
-	_, err := fmt.Fprintf(w, "x = %d, y = %d\n", cols, rows)
+	var err error
+	if r != nil {
+		_, err = fmt.Fprintf(w, "x = %d, y = %d, rule = %s\n", cols, rows, r)
+	} else {
+		_, err = fmt.Fprintf(w, "x = %d, y = %d\n", cols, rows)
+	}
 	if err != nil {
 		return err
 	}
+	writeRun := func(token string, runLen int) error {
+		if runLen == 1 {
+			_, err := fmt.Fprint(w, token)
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%d%s", runLen, token)
+		return err
+	}
 	for y := 0; y < rows; y++ {
-		runChar := byte(0)
+		runToken := ""
 		runLen := 0
 		for x := 0; x < cols; x++ {
-			alive := g.At(minRow+y, minCol+x)
-			c := byte('b')
-			if alive {
-				c = 'o'
-			}
+			t := cellToken(g.At(minRow+y, minCol+x), multiState)
 			if runLen == 0 {
-				runChar = c
+				runToken = t
 				runLen = 1
-			} else if c == runChar {
+			} else if t == runToken {
 				runLen++
 			} else {
-				if runLen == 1 {
-					_, err = fmt.Fprintf(w, "%c", runChar)
-				} else {
-					_, err = fmt.Fprintf(w, "%d%c", runLen, runChar)
-				}
-				if err != nil {
+				if err = writeRun(runToken, runLen); err != nil {
 					return err
 				}
-				runChar = c
+				runToken = t
 				runLen = 1
 			}
 		}
 		if runLen > 0 {
-			if runLen == 1 {
-				_, err = fmt.Fprintf(w, "%c", runChar)
-			} else {
-				_, err = fmt.Fprintf(w, "%d%c", runLen, runChar)
-			}
-			if err != nil {
+			if err = writeRun(runToken, runLen); err != nil {
 				return err
 			}
 		}
@@ -136,3 +164,24 @@ func ExportRLE(w io.Writer, g board.InfiniteGrid) error {
 	_, err = fmt.Fprintf(w, "!\n")
 	return err
 }
+
+// cellToken returns the RLE encoding of a cell state.
+// Two-state patterns use 'b' and 'o', multi-state patterns use '.' for dead
+// and 'A'..'X' for states 1-24, with a 'p'..'y' prefix for higher states.
+func cellToken(state board.Cell, multiState bool) string {
+	if !multiState {
+		if state.IsAlive() {
+			return "o"
+		}
+		return "b"
+	}
+	if state == board.Dead {
+		return "."
+	}
+	n := int(state) - 1
+	letter := string(rune('A' + n%24))
+	if n < 24 {
+		return letter
+	}
+	return string(rune('p'+n/24-1)) + letter
+}
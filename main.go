@@ -4,21 +4,71 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/kvitebjorn/gol/internal/board"
 	"github.com/kvitebjorn/gol/internal/game"
 	"github.com/kvitebjorn/gol/internal/gpu"
 	"github.com/kvitebjorn/gol/internal/gui"
+	"github.com/kvitebjorn/gol/internal/rule"
 	"github.com/kvitebjorn/gol/internal/util"
 )
 
 func main() {
-	game.UseGpu = gpu.HasCUDA()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			doctor(os.Args[2:])
+			return
+		case "analyze":
+			analyze(os.Args[2:])
+			return
+		}
+	}
 
 	rleFile := flag.String("rle", "", "Path to RLE file to import as initial pattern")
+	ruleStr := flag.String("rule", "B3/S23", "Life-like rule in B/S notation, e.g. B36/S23 for HighLife")
+	boardName := flag.String("board", board.Default(), fmt.Sprintf("Board storage, one of: %s", strings.Join(board.Names(), ", ")))
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of goroutines that compute a generation on the CPU")
+	backend := flag.String("backend", game.BackendName(), fmt.Sprintf("Compute backend, one of: %s", strings.Join(game.BackendNames(), ", ")))
+	trackChanges := flag.Bool("track-changes", false, "Only re-evaluate the parts of the board that changed in the previous generation")
 	flag.Parse()
 
-	var imported *board.InfiniteGrid
+	if err := board.SetDefault(*boardName); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to select board: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := game.SetBackend(*backend); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to select backend: %v\n", err)
+		os.Exit(1)
+	}
+	if *backend == "cuda" && !gpu.HasCUDA() {
+		fmt.Fprintln(os.Stderr, "The cuda backend needs an NVIDIA GPU and the CUDA runtime, run `gol doctor` to see why it wasn't found")
+		os.Exit(1)
+	}
+
+	if *workers < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid number of workers: %d\n", *workers)
+		os.Exit(1)
+	}
+	game.Workers = *workers
+	game.TrackChanges = *trackChanges
+
+	r, err := rule.Parse(*ruleStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse rule: %v\n", err)
+		os.Exit(1)
+	}
+	ruleFlagSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "rule" {
+			ruleFlagSet = true
+		}
+	})
+
+	var imported board.Board
 	if *rleFile != "" {
 		f, err := os.Open(*rleFile)
 		if err != nil {
@@ -26,12 +76,16 @@ func main() {
 			os.Exit(1)
 		}
 		defer f.Close()
-		b, err := util.ImportRLE(f)
+		b, rleRule, err := util.ImportRLE(f)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to import RLE: %v\n", err)
 			os.Exit(1)
 		}
-		imported = &b
+		imported = b
+		// The file's rule applies unless one was explicitly requested on the command line
+		if rleRule != nil && !ruleFlagSet {
+			r = rleRule
+		}
 	}
-	gui.RunGUI(imported)
+	gui.RunGUI(imported, r)
 }
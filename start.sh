@@ -1 +1 @@
-LD_LIBRARY_PATH="$(dirname "$0")" "$(dirname "$0")/gol"
+LD_LIBRARY_PATH="$(dirname "$0")" "$(dirname "$0")/gol" "$@"
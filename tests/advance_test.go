@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestAdvanceMatchesTick(t *testing.T) {
+	// Conway's Life jumps with HashLife; Brian's Brain steps one generation at a time
+	for _, rs := range []string{"B3/S23", "B2/S/C3"} {
+		for _, n := range []int{0, 5, 64, 100} {
+			soup := randomSoup(6, 20)
+			slow := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+			fast := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+			for i := 0; i < n; i++ {
+				slow.Tick()
+			}
+			last := 0
+			err := fast.Advance(context.Background(), n, func(done, total int) {
+				if done <= last || done > total || total != n {
+					t.Errorf("%s: progress(%d, %d) after %d", rs, done, total, last)
+				}
+				last = done
+			})
+			if err != nil {
+				t.Fatalf("%s: Advance(%d) failed: %v", rs, n, err)
+			}
+			if last != n {
+				t.Errorf("%s: last progress was %d, want %d", rs, last, n)
+			}
+			if fast.Turn != slow.Turn || !gridsEqual(fast.CurrentBoard(), slow.CurrentBoard()) {
+				t.Errorf("%s: Advance(%d) differs from %d ticks", rs, n, n)
+			}
+		}
+	}
+}
+
+func TestAdvanceCancel(t *testing.T) {
+	// Stepping one generation at a time, as on the CPU
+	defer game.SetBackend(game.BackendName())
+	game.SetBackend("cpu")
+	g := game.Game{BoardA: randomSoup(2, 20), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B2/S/C3")}
+	ctx, cancel := context.WithCancel(context.Background())
+	err := g.Advance(ctx, 1000, func(done, total int) {
+		if done == 7 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Advance should return context.Canceled, got %v", err)
+	}
+	if g.Turn != 8 {
+		t.Errorf("a cancelled Advance should stop after the current generation, Turn = %d, want 8", g.Turn)
+	}
+
+	if err := g.Advance(ctx, 10, nil); !errors.Is(err, context.Canceled) || g.Turn != 8 {
+		t.Errorf("Advance with a cancelled context should not step, got Turn %d and %v", g.Turn, err)
+	}
+	if err := g.Advance(context.Background(), -1, nil); err == nil {
+		t.Errorf("Advance should fail for a negative number of generations")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+// ratsArray returns n x n copies of the period-6 $rats oscillator on a board of
+// the given implementation, so its population and extent repeat every 6 generations.
+func ratsArray(t testing.TB, name string, n int) board.Board {
+	f, err := os.Open("../assets/sample-patterns/rats.rle")
+	if err != nil {
+		t.Fatalf("failed to open sample pattern: %v", err)
+	}
+	defer f.Close()
+	rats, _, err := util.ImportRLE(f)
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	out, _ := board.New(name)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for pos, state := range rats.All() {
+				out.Set(pos[0]+20*i, pos[1]+20*j, state)
+			}
+		}
+	}
+	return out
+}
+
+func steadyGame(t testing.TB, name string) *game.Game {
+	start := ratsArray(t, name, 16)
+	next, _ := board.New(name)
+	g := &game.Game{BoardA: start, BoardB: next, UseA: true, Turn: 1}
+	// Let both buffers grow to the size of the largest phase
+	for i := 0; i < 12; i++ {
+		g.Tick()
+	}
+	return g
+}
+
+func TestTickCpuSteadyStateAllocs(t *testing.T) {
+	for _, name := range []string{"map", "tiled"} {
+		g := steadyGame(t, name)
+		// A cleared Go map may still split a table now and then, since clear
+		// reseeds its hash; that costs a handful of allocations, not one per cell
+		allocs := testing.AllocsPerRun(60, g.Tick)
+		if allocs > 10 {
+			t.Errorf("%s: Tick allocates %.1f times per generation in a steady state, want at most 10", name, allocs)
+		}
+	}
+}
+
+func TestClearKeepsBoundsValid(t *testing.T) {
+	g := board.NewInfiniteGrid()
+	g.Set(-4, 9, board.Alive)
+	g.Clear()
+	g.Set(3, 5, board.Alive)
+	g.Set(7, 2, board.Alive)
+	if !g.BoundsValid {
+		t.Errorf("bounds should stay valid while cells are added after Clear")
+	}
+	if r0, c0, r1, c1 := g.Bounds(); r0 != 3 || c0 != 2 || r1 != 7 || c1 != 5 {
+		t.Errorf("Bounds() = (%d,%d,%d,%d), want (3,2,7,5)", r0, c0, r1, c1)
+	}
+}
+
+func BenchmarkTickSteadyState(b *testing.B) {
+	for _, name := range []string{"map", "tiled"} {
+		b.Run(name, func(b *testing.B) {
+			g := steadyGame(b, name)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.Tick()
+			}
+		})
+	}
+}
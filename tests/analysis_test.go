@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/analysis"
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestHashIsTranslationInvariant(t *testing.T) {
+	soup := randomSoup(11, 16)
+	h, row, col := analysis.Hash(soup)
+	moved := transform(soup, func(r, c int) (int, int) { return r - 1000, c + 37 })
+	h2, row2, col2 := analysis.Hash(moved)
+	if h != h2 || row2-row != -1000 || col2-col != 37 {
+		t.Errorf("a moved pattern should hash the same, at its new corner")
+	}
+	mirrored := transform(soup, func(r, c int) (int, int) { return r, -c })
+	if h3, _, _ := analysis.Hash(mirrored); h3 == h {
+		t.Errorf("a mirrored soup should hash differently")
+	}
+	dying := soup.DeepCopy()
+	for pos := range soup.All() {
+		dying.Set(pos[0], pos[1], 2)
+		break
+	}
+	if h4, _, _ := analysis.Hash(dying); h4 == h {
+		t.Errorf("cell states should be part of the hash")
+	}
+}
+
+func TestAnalysis(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		rule    string
+		pattern []string
+		want    analysis.Result
+		speed   string
+	}{
+		{"block", "B3/S23", []string{"OO", "OO"}, analysis.Result{Kind: analysis.StillLife, Start: 1, Period: 1}, "0"},
+		{"pre-block", "B3/S23", []string{"OO", "O."}, analysis.Result{Kind: analysis.StillLife, Start: 2, Period: 1}, "0"},
+		{"blinker", "B3/S23", []string{"OOO"}, analysis.Result{Kind: analysis.Oscillator, Start: 1, Period: 2}, "0"},
+		{"pulsar", "B3/S23", []string{
+			"..OOO...OOO..",
+			".............",
+			"O....O.O....O",
+			"O....O.O....O",
+			"O....O.O....O",
+			"..OOO...OOO..",
+			".............",
+			"..OOO...OOO..",
+			"O....O.O....O",
+			"O....O.O....O",
+			"O....O.O....O",
+			".............",
+			"..OOO...OOO..",
+		}, analysis.Result{Kind: analysis.Oscillator, Start: 1, Period: 3}, "0"},
+		{"glider", "B3/S23", []string{".O.", "..O", "OOO"}, analysis.Result{Kind: analysis.Spaceship, Start: 1, Period: 4, Dx: 1, Dy: 1}, "c/4"},
+		{"LWSS", "B3/S23", []string{".O..O", "O....", "O...O", "OOOO."}, analysis.Result{Kind: analysis.Spaceship, Start: 1, Period: 4, Dx: -2}, "c/2"},
+		{"domino", "B3/S23", []string{"OO"}, analysis.Result{Kind: analysis.Dead, Start: 2, Period: 1}, "0"},
+	} {
+		g := game.Game{BoardA: parsePattern(tc.pattern, 0, 0), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(tc.rule)}
+		got, err := analysis.Run(context.Background(), &g, 100)
+		if err != nil {
+			t.Fatalf("%s: Run failed: %v", tc.name, err)
+		}
+		got.Generations = 0
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+		if got.Speed() != tc.speed {
+			t.Errorf("%s: speed %s, want %s", tc.name, got.Speed(), tc.speed)
+		}
+	}
+}
+
+func TestAnalysisGenerations(t *testing.T) {
+	// Brian's Brain's c/1 ship: two live cells pushed up by two dying ones
+	ship := parsePattern([]string{"OO"}, 0, 0)
+	ship.Set(1, 0, 2)
+	ship.Set(1, 1, 2)
+	g := game.Game{BoardA: ship, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B2/S/C3")}
+	got, _ := analysis.Run(context.Background(), &g, 100)
+	if got.Kind != analysis.Spaceship || got.Period != 1 || got.Dx != 0 || got.Dy != -1 || got.Speed() != "c" {
+		t.Errorf("got %v, want a c/1 spaceship moving up", got)
+	}
+}
+
+func TestAnalysisOnTorus(t *testing.T) {
+	// The glider crosses the seam, so it is only seen again where it started, after
+	// lapping the 8x8 torus in 32 generations
+	g := game.Game{BoardA: parsePattern([]string{".O.", "..O", "OOO"}, 0, 0), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B3/S23:T8,8")}
+	got, err := analysis.Run(context.Background(), &g, 100)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := analysis.Result{Kind: analysis.Oscillator, Start: 1, Period: 32, Generations: 33, Wrapped: true}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A plane doesn't wrap, so its patterns are still compared up to a translation
+	g = game.Game{BoardA: parsePattern([]string{".O.", "..O", "OOO"}, 0, 0), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B3/S23:P40,40")}
+	if got, _ := analysis.Run(context.Background(), &g, 100); got.Kind != analysis.Spaceship || got.Wrapped {
+		t.Errorf("glider on a plane: got %+v, want a spaceship", got)
+	}
+}
+
+func TestAnalysisGivesUp(t *testing.T) {
+	// The R-pentomino takes 1103 generations to settle
+	g := game.Game{BoardA: parsePattern([]string{".OO", "OO.", ".O."}, 0, 0), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	got, err := analysis.Run(context.Background(), &g, 200)
+	if err != nil || got.Kind != analysis.Unknown || got.Generations != 201 || g.Turn != 201 {
+		t.Errorf("Run(200) on the R-pentomino returned %v, %v at generation %d, want no result after 201 generations", got, err, g.Turn)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := analysis.Run(ctx, &g, 200); err != context.Canceled {
+		t.Errorf("Run with a cancelled context returned %v, want context.Canceled", err)
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	for _, tc := range []struct {
+		r         analysis.Result
+		speed     string
+		direction string
+	}{
+		{analysis.Result{Period: 4, Dx: 1, Dy: -1}, "c/4", "diagonal"},
+		{analysis.Result{Period: 4, Dx: 0, Dy: 2}, "c/2", "orthogonal"},
+		{analysis.Result{Period: 5, Dx: 2, Dy: 0}, "2c/5", "orthogonal"},
+		{analysis.Result{Period: 6, Dx: 2, Dy: 1}, "c/3", "oblique"},
+		{analysis.Result{Period: 2, Dx: 0, Dy: 2}, "c", "orthogonal"},
+	} {
+		if got := tc.r.Speed(); got != tc.speed {
+			t.Errorf("%+v: Speed() = %s, want %s", tc.r, got, tc.speed)
+		}
+		if got := tc.r.Direction(); got != tc.direction {
+			t.Errorf("%+v: Direction() = %s, want %s", tc.r, got, tc.direction)
+		}
+	}
+}
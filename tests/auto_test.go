@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/gpu"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// fakeGpu makes the auto backend see a GPU, run on the CPU stand-in, and
+// returns the switches it logs.
+func fakeGpu(t *testing.T) *[]string {
+	name, hasGpu, dev, logf := game.BackendName(), game.HasGpu, game.NewDevice, game.Logf
+	t.Cleanup(func() {
+		game.SetBackend(name)
+		game.HasGpu, game.NewDevice, game.Logf = hasGpu, dev, logf
+	})
+	var logged []string
+	game.SetBackend("auto")
+	game.HasGpu = func() bool { return true }
+	game.NewDevice = gpu.NewCPUDevice
+	game.Logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	return &logged
+}
+
+// blocks returns n 2x2 blocks, 4 cells apart, in rows of width blocks.
+func blocks(n, width int) *board.InfiniteGrid {
+	g := board.NewInfiniteGrid()
+	for i := 0; i < n; i++ {
+		row, col := 4*(i/width), 4*(i%width)
+		for _, d := range [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+			g.Set(row+d[0], col+d[1], board.Alive)
+		}
+	}
+	return g
+}
+
+func TestAutoBackendChoice(t *testing.T) {
+	fakeGpu(t)
+	// A line of blocks far apart: plenty of cells, but a mostly empty bounding box
+	sparse := transform(blocks(2000, 2000), func(row, col int) (int, int) { return row + col*10, col * 10 })
+	for _, tc := range []struct {
+		name  string
+		start board.Board
+		rule  string
+		want  string
+	}{
+		{"dense soup", randomSoup(1, 100), "B3/S23", "cuda"},
+		{"small soup", randomSoup(1, 40), "B3/S23", "cpu"},
+		{"sparse blocks", sparse, "B3/S23", "cpu"},
+		{"unsupported rule", randomSoup(1, 100), "R2,C0,M1,S2..3,B3..3,NM", "cpu"},
+	} {
+		g := game.Game{BoardA: tc.start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(tc.rule)}
+		g.Tick()
+		d := g.BackendDecision()
+		if d.Backend != tc.want || d.Turn != 1 || d.Population != tc.start.Len() {
+			t.Errorf("%s: decision %+v, want the %s backend at turn 1 with population %d", tc.name, d, tc.want, tc.start.Len())
+		}
+	}
+}
+
+func TestAutoBackendHysteresis(t *testing.T) {
+	logged := fakeGpu(t)
+	// Between the thresholds: still lifes only move to the GPU if already there
+	between := blocks(800, 28)
+	g := game.Game{BoardA: between.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	g.Tick()
+	if d := g.BackendDecision(); d.Backend != "cpu" {
+		t.Fatalf("%d cells should start on the CPU, got %+v", between.Len(), d)
+	}
+
+	g = game.Game{BoardA: randomSoup(2, 100), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	g.Tick()
+	if d := g.BackendDecision(); d.Backend != "cuda" {
+		t.Fatalf("a dense soup should start on the GPU, got %+v", d)
+	}
+	replace := func(b board.Board) {
+		for pos := range g.CurrentBoard().Clone().All() {
+			g.SetCell(pos[0], pos[1], board.Dead)
+		}
+		for pos, state := range b.All() {
+			g.SetCell(pos[0], pos[1], state)
+		}
+	}
+	replace(between)
+	for i := 0; i < 40; i++ {
+		g.Tick()
+	}
+	if d := g.BackendDecision(); d.Backend != "cuda" || d.Turn < 34 {
+		t.Errorf("%d cells should stay on the GPU, got %+v", between.Len(), d)
+	}
+	if len(*logged) != 0 {
+		t.Errorf("nothing should have switched, got %q", *logged)
+	}
+
+	replace(blocks(400, 28))
+	g.Tick()
+	if d := g.BackendDecision(); d.Backend != "cpu" {
+		t.Errorf("an edit down to %d cells should move to the CPU, got %+v", g.CurrentBoard().Len(), d)
+	}
+	if len(*logged) != 1 {
+		t.Errorf("the switch should have been logged once, got %q", *logged)
+	}
+}
+
+func TestAutoBackendMatchesCpu(t *testing.T) {
+	logged := fakeGpu(t)
+	// A soup dense enough for the GPU, which decays into sparse ash the CPU is better at
+	for _, rs := range []string{"B3/S23", "B3/S2-i34q"} {
+		*logged = nil
+		r := rule.MustParse(rs)
+		soup := randomSoup(3, 100)
+		got := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+		want := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+		used := map[string]bool{}
+		for i := 0; i < 150; i++ {
+			got.Tick()
+			want.TickCpu(want.BoardA, want.BoardB)
+			want.BoardA, want.BoardB = want.BoardB, want.BoardA
+			used[got.BackendDecision().Backend] = true
+			if !gridsEqual(got.CurrentBoard(), want.BoardA) {
+				t.Fatalf("%s: the auto backend differs from TickCpu at generation %d on %s", rs, i+2, got.BackendDecision().Backend)
+			}
+		}
+		if !used["cuda"] || !used["cpu"] || len(*logged) == 0 {
+			t.Errorf("%s: the soup should have run on both backends, logged %q", rs, *logged)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// countingBackend computes generations on the CPU, counting them, for games
+// of two-state rules only.
+type countingBackend struct {
+	ticks *int
+}
+
+func (b countingBackend) Supports(g *game.Game) bool {
+	return g.ActiveRule().States <= 2
+}
+
+func (b countingBackend) Tick(g *game.Game, src, dst board.Board) {
+	*b.ticks++
+	g.TickCpu(src, dst)
+}
+
+var countedTicks int
+
+func init() {
+	game.RegisterBackend("counting", countingBackend{&countedTicks})
+}
+
+func TestBackendRegistry(t *testing.T) {
+	for _, name := range []string{"auto", "cpu", "cuda", "dense", "counting"} {
+		if !slices.Contains(game.BackendNames(), name) {
+			t.Errorf("BackendNames() = %v, should contain %q", game.BackendNames(), name)
+		}
+	}
+	if _, err := game.LookupBackend("no-such-backend"); err == nil {
+		t.Errorf("LookupBackend should fail for an unknown backend")
+	}
+	if err := game.SetBackend("no-such-backend"); err == nil {
+		t.Errorf("SetBackend should fail for an unknown backend")
+	}
+	if game.BackendName() != "auto" {
+		t.Errorf("BackendName() = %q, want auto by default", game.BackendName())
+	}
+}
+
+func TestTickUsesBackend(t *testing.T) {
+	defer game.SetBackend(game.BackendName())
+	if err := game.SetBackend("counting"); err != nil {
+		t.Fatalf("SetBackend failed: %v", err)
+	}
+	for _, tc := range []struct {
+		rule  string
+		ticks int
+	}{{"B3/S23", 5}, {"B2/S/C3", 0}} {
+		r := rule.MustParse(tc.rule)
+		soup := randomSoup(4, 12)
+		g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+		countedTicks = 0
+		for i := 0; i < 5; i++ {
+			g.Tick()
+		}
+		// Generations rules aren't supported, so they run on the CPU backend
+		if countedTicks != tc.ticks {
+			t.Errorf("%s: the backend computed %d generations, want %d", tc.rule, countedTicks, tc.ticks)
+		}
+		if !gridsEqual(g.CurrentBoard(), runCpu(soup, r, 5)) {
+			t.Errorf("%s: differs from TickCpu", tc.rule)
+		}
+	}
+}
+
+func TestDenseBackendMatchesTickCpu(t *testing.T) {
+	defer game.SetBackend(game.BackendName())
+	if err := game.SetBackend("dense"); err != nil {
+		t.Fatalf("SetBackend failed: %v", err)
+	}
+	soup := transform(randomSoup(6, 24), func(row, col int) (int, int) { return row - 40, col + 7 })
+	for _, rs := range []string{"B3/S23", "B2/S/C3", "B3/S2-i34q", "B2/S34H", "B3/S23:T30,20", "R2,C0,M1,S2..3,B3..3,NM", "B2/S13L"} {
+		r := rule.MustParse(rs)
+		g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+		for i := 0; i < 10; i++ {
+			g.Tick()
+		}
+		if err := g.Advance(context.Background(), 30, nil); err != nil {
+			t.Fatalf("%s: Advance failed: %v", rs, err)
+		}
+		if !gridsEqual(g.CurrentBoard(), runCpu(soup, r, 40)) {
+			t.Errorf("%s: dense backend differs from TickCpu after 40 generations", rs)
+		}
+	}
+}
+
+func BenchmarkDenseBackend(b *testing.B) {
+	defer game.SetBackend(game.BackendName())
+	for _, name := range []string{"cpu", "dense"} {
+		b.Run(name, func(b *testing.B) {
+			game.SetBackend(name)
+			g := game.Game{BoardA: randomSoup(1, 128), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.Tick()
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+// loggedBoard is a board implementation defined outside the board package, which
+// records how many cells were written through it.
+type loggedBoard struct {
+	*board.InfiniteGrid
+	sets *int
+}
+
+func (b loggedBoard) Set(row, col int, val board.Cell) {
+	*b.sets++
+	b.InfiniteGrid.Set(row, col, val)
+}
+
+func (b loggedBoard) Clone() board.Board {
+	return loggedBoard{b.InfiniteGrid.DeepCopy(), b.sets}
+}
+
+var loggedSets int
+
+func init() {
+	board.Register("logged", func() board.Board {
+		return loggedBoard{board.NewInfiniteGrid(), &loggedSets}
+	})
+}
+
+// TestBoardImplementations checks the Board contract for every registered implementation.
+func TestBoardImplementations(t *testing.T) {
+	for _, name := range board.Names() {
+		b, err := board.New(name)
+		if err != nil {
+			t.Fatalf("New(%q) failed: %v", name, err)
+		}
+		if b.Len() != 0 {
+			t.Errorf("%s: a new board should be empty", name)
+		}
+		if r0, c0, r1, c1 := b.Bounds(); r0 != 0 || c0 != 0 || r1 != 0 || c1 != 0 {
+			t.Errorf("%s: Bounds() of an empty board = (%d,%d,%d,%d), want zeros", name, r0, c0, r1, c1)
+		}
+
+		cells := map[[2]int]board.Cell{{-70, 3}: board.Alive, {5, -2}: 3, {5, 130}: board.Alive, {64, 64}: board.Alive}
+		for pos, state := range cells {
+			b.Set(pos[0], pos[1], state)
+		}
+		if b.Len() != len(cells) {
+			t.Errorf("%s: Len() = %d, want %d", name, b.Len(), len(cells))
+		}
+		for pos, state := range b.All() {
+			if cells[pos] != state {
+				t.Errorf("%s: All() yielded %v = %d, want %d", name, pos, state, cells[pos])
+			}
+		}
+		if got := b.At(5, -2); got != 3 {
+			t.Errorf("%s: At(5, -2) = %d, want 3", name, got)
+		}
+		if r0, c0, r1, c1 := b.Bounds(); r0 != -70 || c0 != -2 || r1 != 64 || c1 != 130 {
+			t.Errorf("%s: Bounds() = (%d,%d,%d,%d), want (-70,-2,64,130)", name, r0, c0, r1, c1)
+		}
+		region := b.AliveCellsWithinBounds(-2, 0, 65, 65)
+		slices.SortFunc(region, func(a, b [2]int) int { return a[0] - b[0] })
+		if !slices.Equal(region, [][2]int{{5, -2}, {64, 64}}) {
+			t.Errorf("%s: AliveCellsWithinBounds = %v, want [[5 -2] [64 64]]", name, region)
+		}
+
+		clone := b.Clone()
+		b.Set(64, 64, board.Dead)
+		if b.At(64, 64) != board.Dead || b.Len() != len(cells)-1 {
+			t.Errorf("%s: setting a cell to Dead should remove it", name)
+		}
+		if !clone.At(64, 64).IsAlive() || clone.Len() != len(cells) {
+			t.Errorf("%s: a clone should not change with the original", name)
+		}
+
+		b.Clear()
+		if b.Len() != 0 || b.At(-70, 3) != board.Dead {
+			t.Errorf("%s: Clear() should remove every cell", name)
+		}
+	}
+}
+
+func TestBoardRegistry(t *testing.T) {
+	if !slices.Contains(board.Names(), "map") {
+		t.Errorf("Names() = %v, should contain the map board", board.Names())
+	}
+	if _, err := board.New("no-such-board"); err == nil {
+		t.Errorf("New should fail for an unknown board")
+	}
+	if err := board.SetDefault("no-such-board"); err == nil {
+		t.Errorf("SetDefault should fail for an unknown board")
+	}
+
+	defer board.SetDefault(board.Default())
+	if err := board.SetDefault("logged"); err != nil {
+		t.Fatalf("SetDefault failed: %v", err)
+	}
+	b, _, err := util.ImportRLE(strings.NewReader("x = 3, y = 1\n3o!\n"))
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	if _, ok := b.(loggedBoard); !ok {
+		t.Errorf("ImportRLE should create boards of the default implementation, got %T", b)
+	}
+}
+
+func TestGameRunsOnCustomBoard(t *testing.T) {
+	blinker := makeInfiniteGrid([][]bool{{true, true, true}})
+	loggedSets = 0
+	g := game.Game{
+		BoardA: loggedBoard{blinker.DeepCopy(), &loggedSets},
+		BoardB: loggedBoard{board.NewInfiniteGrid(), &loggedSets},
+		UseA:   true,
+		Turn:   1,
+	}
+	g.Tick()
+	g.Tick()
+	if !gridsEqual(g.CurrentBoard(), blinker) {
+		t.Errorf("blinker should return to its start on a custom board")
+	}
+	if loggedSets == 0 {
+		t.Errorf("the game should write generations through the board's Set")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/gpu"
+)
+
+// fakeLoader opens the libraries listed in libs, whose driver and runtime report
+// the versions, devices and errors it is given.
+type fakeLoader struct {
+	libs    []string
+	initErr error
+	devices []string
+}
+
+type fakeLib struct{ l *fakeLoader }
+
+func (f *fakeLoader) open(name string) error {
+	if !slices.Contains(f.libs, name) {
+		return errors.New(name + ": cannot open shared object file")
+	}
+	return nil
+}
+
+func (f *fakeLoader) OpenDriver(name string) (gpu.Driver, error) {
+	if err := f.open(name); err != nil {
+		return nil, err
+	}
+	return fakeLib{f}, nil
+}
+
+func (f *fakeLoader) OpenRuntime(name string) (gpu.Runtime, error) {
+	if err := f.open(name); err != nil {
+		return nil, err
+	}
+	return fakeLib{f}, nil
+}
+
+func (f *fakeLoader) OpenKernels() error {
+	return f.open("libgpu.so")
+}
+
+func (d fakeLib) Init() error               { return d.l.initErr }
+func (d fakeLib) Version() (int, error)     { return 12040, nil }
+func (d fakeLib) DeviceCount() (int, error) { return len(d.l.devices), nil }
+func (d fakeLib) DeviceName(ordinal int) (string, error) {
+	return d.l.devices[ordinal], nil
+}
+func (d fakeLib) Close() {}
+
+// failedCheck returns the name of the first failed detection step, or "".
+func failedCheck(r gpu.Report) string {
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+func TestDetect(t *testing.T) {
+	gpus := []string{"NVIDIA GeForce RTX 4090", "NVIDIA A100"}
+	for _, tc := range []struct {
+		name   string
+		loader fakeLoader
+		failed string
+		tried  int
+	}{
+		{"no driver", fakeLoader{libs: []string{"libcudart.so", "libgpu.so"}, devices: gpus}, "CUDA driver library", 4},
+		{"no runtime", fakeLoader{libs: []string{"libcuda.so.1", "libgpu.so"}, devices: gpus}, "CUDA runtime library", 8},
+		{"no kernels", fakeLoader{libs: []string{"libcuda.so", "libcudart.so"}, devices: gpus}, "Kernel library", 3},
+		{"cuInit fails", fakeLoader{libs: []string{"libcuda.so", "libcudart.so.12.0", "libgpu.so"}, initErr: errors.New("cuInit: CUDA driver error 100"), devices: gpus}, "Driver initialization", 5},
+		{"no devices", fakeLoader{libs: []string{"libcuda.so", "libcudart.so", "libgpu.so"}}, "CUDA devices", 3},
+		{"available", fakeLoader{libs: []string{"libcuda.so", "libcudart.so.12.0", "libgpu.so"}, devices: gpus}, "", 5},
+	} {
+		r := gpu.Detect(&tc.loader)
+		if got := failedCheck(r); got != tc.failed {
+			t.Errorf("%s: first failed step is %q, want %q", tc.name, got, tc.failed)
+		}
+		if len(r.Tried) != tc.tried {
+			t.Errorf("%s: tried %d libraries, want %d: %v", tc.name, len(r.Tried), tc.tried, r.Tried)
+		}
+		if r.Available != (tc.failed == "") {
+			t.Errorf("%s: Available = %v, want %v", tc.name, r.Available, tc.failed == "")
+		}
+	}
+
+	r := gpu.Detect(&fakeLoader{libs: []string{"libcuda.so.1", "libcudart.so.12.0", "libgpu.so"}, devices: gpus})
+	if r.DriverLib != "libcuda.so.1" || r.RuntimeLib != "libcudart.so.12.0" {
+		t.Errorf("opened %q and %q, want libcuda.so.1 and libcudart.so.12.0", r.DriverLib, r.RuntimeLib)
+	}
+	if r.DriverVersion != 12040 || r.RuntimeVersion != 12040 {
+		t.Errorf("versions %d and %d, want 12040", r.DriverVersion, r.RuntimeVersion)
+	}
+	if !slices.Equal(r.Devices, gpus) {
+		t.Errorf("Devices = %v, want %v", r.Devices, gpus)
+	}
+	if r.Tried[0].Err == nil || r.Tried[1].Err != nil {
+		t.Errorf("libcuda.so should have failed to open and libcuda.so.1 opened: %v", r.Tried)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestUndoRedoCellEdits(t *testing.T) {
+	soup := randomSoup(3, 10)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	var log game.EditLog
+
+	// A stroke that crosses itself is undone as one edit
+	var stroke game.CellEdit
+	for _, c := range [][2]int{{20, 20}, {20, 21}, {20, 22}, {20, 21}} {
+		stroke.Set(c[0], c[1], board.Alive)
+	}
+	stroke.Set(20, 21, board.Dead)
+	log.Do(&g, &stroke)
+	var toggle game.CellEdit
+	toggle.Set(0, 0, 1-g.CurrentBoard().At(0, 0))
+	log.Do(&g, &toggle)
+	edited := g.CurrentBoard().Clone()
+
+	if !log.Undo(&g) || !log.Undo(&g) {
+		t.Fatalf("both edits should be undone")
+	}
+	if !gridsEqual(g.CurrentBoard(), soup) {
+		t.Errorf("undoing every edit should bring back the board before them")
+	}
+	if log.Undo(&g) {
+		t.Errorf("Undo with nothing left to undo should report false")
+	}
+	if !log.Redo(&g) || !log.Redo(&g) || log.Redo(&g) {
+		t.Fatalf("both edits, and only them, should be redone")
+	}
+	if !gridsEqual(g.CurrentBoard(), edited) {
+		t.Errorf("redoing every edit should bring back the edited board")
+	}
+
+	// A new edit forgets the undone ones
+	log.Undo(&g)
+	var other game.CellEdit
+	other.Set(-5, -5, board.Alive)
+	log.Do(&g, &other)
+	if log.CanRedo(&g) {
+		t.Errorf("an edit should clear the edits undone before it")
+	}
+}
+
+func TestUndoIsSeparateFromGenerations(t *testing.T) {
+	soup := randomSoup(4, 12)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, History: game.NewHistory(100)}
+	var log game.EditLog
+	for i := 0; i < 5; i++ {
+		g.Tick()
+	}
+	var e game.CellEdit
+	e.Set(30, 30, board.Alive)
+	log.Do(&g, &e)
+	edited := g.CurrentBoard().Clone()
+	g.Tick()
+
+	// Undo doesn't step generations back
+	if log.CanUndo(&g) || log.Undo(&g) || g.Turn != 7 {
+		t.Errorf("an edit should only be undone at the generation it was made at")
+	}
+	if err := g.Seek(6); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if !gridsEqual(g.CurrentBoard(), edited) {
+		t.Fatalf("Seek should go back to the edited generation")
+	}
+	if !log.Undo(&g) || !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 5)) {
+		t.Errorf("the edit should be undone once the game is back at its generation")
+	}
+}
+
+// replaceEdit swaps the game for another, as importing a pattern does.
+type replaceEdit struct {
+	next, prev game.Game
+}
+
+func (e *replaceEdit) Apply(g *game.Game)  { e.prev = *g; *g = e.next }
+func (e *replaceEdit) Revert(g *game.Game) { *g = e.prev }
+
+func TestUndoImport(t *testing.T) {
+	g := game.Game{BoardA: randomSoup(5, 10), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	for i := 0; i < 8; i++ {
+		g.Tick()
+	}
+	before := g.CurrentBoard().Clone()
+	var log game.EditLog
+	imported := randomSoup(6, 10)
+	log.Do(&g, &replaceEdit{next: game.Game{BoardA: imported.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}})
+	if !gridsEqual(g.CurrentBoard(), imported) || g.Turn != 1 {
+		t.Fatalf("the import should replace the game")
+	}
+	if !log.Undo(&g) || g.Turn != 9 || !gridsEqual(g.CurrentBoard(), before) {
+		t.Errorf("undoing the import should bring back the game at generation 9")
+	}
+	if !log.Redo(&g) || g.Turn != 1 || !gridsEqual(g.CurrentBoard(), imported) {
+		t.Errorf("redoing the import should replace the game again")
+	}
+}
@@ -0,0 +1,148 @@
+//go:build cgo && !nocuda && fakecuda
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/gpu"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// These tests run the cgo side of the gpu package on the CPU stand-in for the
+// CUDA runtime and kernels of the fakecuda build tag. Sessions other tests leave
+// open hold device memory, so leaks are measured from what was in use before.
+
+func TestFakeCUDATickMatchesTickCpu(t *testing.T) {
+	defer gpu.FakeReset()
+	inUse := gpu.FakeInUse()
+	for _, rs := range []string{"B3/S23", "B2/S/C3", "B3/S2-i34q", "B2/S34H", "B3/S23:T30,20", "B3/S23:T30+3,20", "B3/S23:K30*,20", "B3/S23:C25,25", "B3/S23:P30,20"} {
+		r := rule.MustParse(rs)
+		// Cells on both sides of the origin, and on both sides of tile edges
+		start := transform(randomSoup(7, 20), func(row, col int) (int, int) { return row - 10, col - 45 })
+		if !r.Topology.Bounded() {
+			for pos, state := range scattered().All() {
+				start.Set(pos[0], pos[1], state)
+			}
+		}
+		want := runCpu(start, r, 1)
+		got := board.NewInfiniteGrid()
+		if err := gpu.Tick(start, got, r, r.Topology); err != nil {
+			t.Fatalf("%s: Tick failed: %v", rs, err)
+		}
+		if !gridsEqual(got, want) {
+			t.Errorf("%s: gpu.Tick differs from TickCpu", rs)
+		}
+		if n := gpu.FakeInUse() - inUse; n > 0 {
+			t.Errorf("%s: Tick left %d bytes of device memory allocated", rs, n)
+		}
+	}
+}
+
+func TestFakeCUDASession(t *testing.T) {
+	defer gpu.FakeReset()
+	inUse := gpu.FakeInUse()
+	glider := parsePattern([]string{".O.", "..O", "OOO"}, 0, 0)
+	s, err := gpu.NewSession(gpu.NewCUDADevice(), glider, rule.Conway, board.Topology{})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	if err := s.Step(400); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	got := board.NewInfiniteGrid()
+	if err := s.Store(got); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if !gridsEqual(got, transform(glider, func(row, col int) (int, int) { return row + 100, col + 100 })) {
+		t.Errorf("glider should have moved 100 cells diagonally on the device")
+	}
+	if s.Uploads() < 2 {
+		t.Errorf("the glider should have been moved to new device grids, Uploads() = %d", s.Uploads())
+	}
+	s.Close()
+	if n := gpu.FakeInUse() - inUse; n > 0 {
+		t.Errorf("Close left %d bytes of device memory allocated", n)
+	}
+}
+
+func TestFakeCUDAErrors(t *testing.T) {
+	defer gpu.FakeReset()
+	inUse := gpu.FakeInUse()
+	src := randomSoup(3, 10)
+	for _, tc := range []struct {
+		call gpu.FakeCall
+		code int
+		want string
+	}{
+		{gpu.FakeSync, 719, "unspecified launch failure (CUDA error 719)"},
+		{gpu.FakeMemcpy, 700, "an illegal memory access was encountered (CUDA error 700)"},
+		{gpu.FakeMalloc, 2, gpu.ErrOutOfMemory.Error()},
+	} {
+		gpu.FakeReset()
+		gpu.FakeFail(tc.call, tc.code)
+		dst := randomSoup(4, 5)
+		before := dst.DeepCopy()
+		err := gpu.Tick(src, dst, rule.Conway, board.Topology{})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Tick with CUDA error %d returned %v, want an error with %q", tc.code, err, tc.want)
+		}
+		if !gridsEqual(dst, before) {
+			t.Errorf("Tick with CUDA error %d should leave dst unchanged", tc.code)
+		}
+		if n := gpu.FakeInUse() - inUse; n > 0 {
+			t.Errorf("Tick with CUDA error %d left %d bytes of device memory allocated", tc.code, n)
+		}
+	}
+}
+
+func TestFakeCUDAOutOfMemory(t *testing.T) {
+	defer gpu.FakeReset()
+	start := randomSoup(9, 150)
+
+	// Room for a few tiles at a time: the grid is stepped in batches of tiles
+	inUse := gpu.FakeInUse()
+	gpu.FakeLimit(inUse + 5*(34*34+32*32)*4)
+	s, err := gpu.NewSession(gpu.NewCUDADevice(), start, rule.Conway, board.Topology{})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer s.Close()
+	if !s.Tiled() {
+		t.Fatalf("a grid larger than device memory should be stepped in tiles")
+	}
+	stepSession(t, s, start, rule.Conway)
+
+	// No room for a single tile
+	gpu.FakeLimit(inUse + 1000)
+	if err := gpu.Tick(start, board.NewInfiniteGrid(), rule.Conway, board.Topology{}); !errors.Is(err, gpu.ErrOutOfMemory) {
+		t.Errorf("Tick without room for a tile returned %v, want ErrOutOfMemory", err)
+	}
+}
+
+func TestFakeCUDAGameFallsBack(t *testing.T) {
+	defer gpu.FakeReset()
+	defer func(name string, logf func(string, ...any)) { game.SetBackend(name); game.Logf = logf }(game.BackendName(), game.Logf)
+	logged := 0
+	game.Logf = func(string, ...any) { logged++ }
+	game.SetBackend("cuda")
+
+	soup := randomSoup(5, 12)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	for i := 0; i < 6; i++ {
+		if i == 3 {
+			gpu.FakeFail(gpu.FakeSync, 719)
+		}
+		g.Tick()
+	}
+	if !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 6)) {
+		t.Fatalf("generations the GPU failed should be computed on the CPU")
+	}
+	if logged != 3 {
+		t.Errorf("%d failed generations logged, want 3", logged)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/gpu"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// countingDevice is a CPU stand-in for the GPU that counts the transfers between
+// host and device.
+type countingDevice struct {
+	gpu.Device
+	loads, reads, steps *int
+}
+
+func (d countingDevice) Load(cells []int32, rows, cols int, table [512]uint8, states int, t board.Topology) error {
+	*d.loads++
+	return d.Device.Load(cells, rows, cols, table, states, t)
+}
+
+func (d countingDevice) Read(cells []int32) error {
+	*d.reads++
+	return d.Device.Read(cells)
+}
+
+func (d countingDevice) Step(n int) error {
+	*d.steps += n
+	return d.Device.Step(n)
+}
+
+type transfers struct{ loads, reads, steps int }
+
+func (c *transfers) device() gpu.Device {
+	return countingDevice{gpu.NewCPUDevice(), &c.loads, &c.reads, &c.steps}
+}
+
+// runCpu returns a board advanced n generations with TickCpu.
+func runCpu(start board.Board, r *rule.Rule, n int) board.Board {
+	g := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	for i := 0; i < n; i++ {
+		src, dst := g.CurrentBoard(), g.BoardA
+		if g.UseA {
+			dst = g.BoardB
+		}
+		g.TickCpu(src, dst)
+		g.UseA = !g.UseA
+	}
+	return g.CurrentBoard()
+}
+
+func TestSessionMatchesTickCpu(t *testing.T) {
+	for _, rs := range []string{"B3/S23", "B2/S/C3", "B3/S2-i34q", "B2/S34H", "B3/S23:T30,20", "B3/S23:K30*,20", "B3/S23:C25,25", "B3/S23:P30,20"} {
+		r := rule.MustParse(rs)
+		soup := randomSoup(5, 16)
+		var c transfers
+		s, err := gpu.NewSession(c.device(), soup, r, r.Topology)
+		if err != nil {
+			t.Fatalf("%s: NewSession failed: %v", rs, err)
+		}
+		done := 0
+		for _, n := range []int{1, 7, 30, 2, 80} {
+			if err := s.Step(n); err != nil {
+				t.Fatalf("%s: Step(%d) failed: %v", rs, n, err)
+			}
+			done += n
+			got := board.NewInfiniteGrid()
+			if err := s.Store(got); err != nil {
+				t.Fatalf("%s: Store failed: %v", rs, err)
+			}
+			if !gridsEqual(got, runCpu(soup, r, done)) {
+				t.Fatalf("%s: session differs from TickCpu at generation %d", rs, done+1)
+			}
+		}
+		if c.steps != done {
+			t.Errorf("%s: device ran %d generations, want %d", rs, c.steps, done)
+		}
+		s.Close()
+	}
+}
+
+func TestSessionGrowsWithPattern(t *testing.T) {
+	glider := parsePattern([]string{".O.", "..O", "OOO"}, 0, 0)
+	var c transfers
+	s, err := gpu.NewSession(c.device(), glider, rule.Conway, board.Topology{})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer s.Close()
+	rows, cols := s.Size()
+
+	// A glider moves a cell every 4 generations, so it crosses the padding
+	if err := s.Step(1000); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	got := board.NewInfiniteGrid()
+	if err := s.Store(got); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	want := transform(glider, func(row, col int) (int, int) { return row + 250, col + 250 })
+	if !gridsEqual(got, want) {
+		t.Errorf("glider should have moved 250 cells diagonally")
+	}
+	if s.Uploads() < 2 || s.Uploads() != c.loads {
+		t.Errorf("the glider should have been moved to new grids, Uploads() = %d with %d loads", s.Uploads(), c.loads)
+	}
+	// Every move downloads the grid once, and the final Store once more
+	if c.reads != c.loads {
+		t.Errorf("got %d downloads for %d uploads, want one download per move and one for Store", c.reads, c.loads)
+	}
+	if r, c := s.Size(); r > rows+200 || c > cols+200 {
+		t.Errorf("the grid should follow the glider, not grow with its path: %dx%d from %dx%d", r, c, rows, cols)
+	}
+}
+
+func TestGameKeepsPatternOnDevice(t *testing.T) {
+	defer func(name string, dev func() gpu.Device) { game.SetBackend(name); game.NewDevice = dev }(game.BackendName(), game.NewDevice)
+	var c transfers
+	game.SetBackend("cuda")
+	game.NewDevice = c.device
+
+	r := rule.MustParse("B2/S/C3")
+	soup := randomSoup(3, 12)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	for i := 0; i < 10; i++ {
+		g.Tick()
+	}
+	if c.loads != 1 || c.reads != 10 {
+		t.Errorf("10 ticks took %d uploads and %d downloads, want 1 and 10", c.loads, c.reads)
+	}
+	if !gridsEqual(g.CurrentBoard(), runCpu(soup, r, 10)) {
+		t.Fatalf("GPU ticks differ from TickCpu")
+	}
+
+	c = transfers{}
+	if err := g.Advance(context.Background(), 40, nil); err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	if c.loads != 0 || c.reads != 1 {
+		t.Errorf("Advance(40) took %d uploads and %d downloads, want 0 and 1", c.loads, c.reads)
+	}
+	if g.Turn != 51 || !gridsEqual(g.CurrentBoard(), runCpu(soup, r, 50)) {
+		t.Fatalf("Advance on the GPU differs from TickCpu")
+	}
+
+	// An edit has to reach the device
+	c = transfers{}
+	g.SetCell(100, 100, board.Alive)
+	g.Tick()
+	if c.loads != 1 || g.CurrentBoard().At(100, 100) != 2 {
+		t.Errorf("an edit should be uploaded before the next tick")
+	}
+}
+
+// failingDevice is a CPU stand-in for the GPU whose kernel fails from the given
+// step on.
+type failingDevice struct {
+	gpu.Device
+	steps *int
+	from  int
+}
+
+func (d failingDevice) Step(n int) error {
+	*d.steps += n
+	if *d.steps >= d.from {
+		return errors.New("tick: unspecified launch failure (CUDA error 719)")
+	}
+	return d.Device.Step(n)
+}
+
+func TestGameFallsBackToCpu(t *testing.T) {
+	defer func(name string, dev func() gpu.Device, logf func(string, ...any)) {
+		game.SetBackend(name)
+		game.NewDevice, game.Logf = dev, logf
+	}(game.BackendName(), game.NewDevice, game.Logf)
+	var logged []string
+	game.Logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	steps := 0
+	game.SetBackend("cuda")
+	game.NewDevice = func() gpu.Device { return failingDevice{gpu.NewCPUDevice(), &steps, 5} }
+
+	soup := randomSoup(8, 12)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	for i := 0; i < 8; i++ {
+		g.Tick()
+	}
+	if !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 8)) {
+		t.Fatalf("ticks that failed on the GPU should be computed on the CPU")
+	}
+	if len(logged) != 4 || !strings.Contains(logged[0], "generation 6") || !strings.Contains(logged[0], "unspecified launch failure") {
+		t.Errorf("every failed generation should be logged with its error, got %q", logged)
+	}
+
+	logged = nil
+	if err := g.Advance(context.Background(), 20, nil); err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	if g.Turn != 29 || !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 28)) {
+		t.Fatalf("Advance should compute the generations the GPU failed on the CPU")
+	}
+	if len(logged) == 0 {
+		t.Errorf("Advance should log the GPU failure")
+	}
+}
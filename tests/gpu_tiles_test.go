@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/gpu"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+// scattered returns soups and gliders far enough apart that their bounding box
+// is mostly empty, with cells on both sides of the origin.
+func scattered() *board.InfiniteGrid {
+	g := board.NewInfiniteGrid()
+	for _, at := range [][2]int{{0, 0}, {-3000, 5000}, {4000, -2500}} {
+		for pos, state := range randomSoup(uint64(at[0]+at[1]), 20).All() {
+			g.Set(at[0]+pos[0], at[1]+pos[1], state)
+		}
+	}
+	for pos, state := range parsePattern([]string{".O.", "..O", "OOO"}, 1000, 1000).All() {
+		g.Set(pos[0], pos[1], state)
+	}
+	return g
+}
+
+func stepSession(t *testing.T, s *gpu.Session, start board.Board, r *rule.Rule) {
+	t.Helper()
+	done := 0
+	for _, n := range []int{1, 4, 25} {
+		if err := s.Step(n); err != nil {
+			t.Fatalf("%s: Step(%d) failed: %v", r, n, err)
+		}
+		done += n
+		got := board.NewInfiniteGrid()
+		if err := s.Store(got); err != nil {
+			t.Fatalf("%s: Store failed: %v", r, err)
+		}
+		if !gridsEqual(got, runCpu(start, r, done)) {
+			t.Fatalf("%s: tiled session differs from TickCpu at generation %d", r, done+1)
+		}
+	}
+}
+
+func TestSparseSessionSteppedInTiles(t *testing.T) {
+	for _, rs := range []string{"B3/S23", "B2/S/C3", "B2/S34H"} {
+		r := rule.MustParse(rs)
+		start := scattered()
+		var c transfers
+		s, err := gpu.NewSession(c.device(), start, r, board.Topology{})
+		if err != nil {
+			t.Fatalf("%s: NewSession failed: %v", rs, err)
+		}
+		if !s.Tiled() || c.loads != 0 {
+			t.Fatalf("%s: a mostly empty bounding box should be stepped in tiles, not uploaded whole", rs)
+		}
+		stepSession(t, s, start, r)
+		s.Close()
+	}
+}
+
+func TestTilesSplitIntoBatches(t *testing.T) {
+	// Room for a few tiles at a time, and not for the whole grid
+	dev := &gpu.CPUDevice{MaxCells: 5 * (34*34 + 32*32)}
+	start := randomSoup(9, 150)
+	s, err := gpu.NewSession(dev, start, rule.Conway, board.Topology{})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer s.Close()
+	if !s.Tiled() {
+		t.Fatalf("a grid larger than device memory should be stepped in tiles")
+	}
+	stepSession(t, s, start, rule.Conway)
+}
+
+func TestTileLargerThanDevice(t *testing.T) {
+	dev := &gpu.CPUDevice{MaxCells: 1000}
+	s, err := gpu.NewSession(dev, scattered(), rule.Conway, board.Topology{})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.Step(1); !errors.Is(err, gpu.ErrOutOfMemory) {
+		t.Errorf("Step on a device without room for a tile returned %v, want ErrOutOfMemory", err)
+	}
+
+	// A bounded grid has to fit whole
+	r := rule.MustParse("B3/S23:T100,100")
+	if _, err := gpu.NewSession(dev, randomSoup(1, 10), r, r.Topology); !errors.Is(err, gpu.ErrOutOfMemory) {
+		t.Errorf("NewSession of a bounded grid larger than the device returned %v, want ErrOutOfMemory", err)
+	}
+}
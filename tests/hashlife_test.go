@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/hashlife"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+func TestFastForwardMatchesTick(t *testing.T) {
+	for _, rs := range []string{"B3/S23", "B36/S23", "B3/S2-i34q", "B2/S34H"} {
+		soup := randomSoup(21, 16)
+		slow := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+		fast := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+		// Mixed step sizes exercise the cached results of several speeds
+		for _, k := range []int{0, 3, 1, 5, 2, 6, 0} {
+			for i := 0; i < 1<<k; i++ {
+				slow.Tick()
+			}
+			if err := fast.FastForward(k); err != nil {
+				t.Fatalf("%s: FastForward(%d) failed: %v", rs, k, err)
+			}
+			if fast.Turn != slow.Turn {
+				t.Fatalf("%s: Turn = %d after FastForward(%d), want %d", rs, fast.Turn, k, slow.Turn)
+			}
+			if !gridsEqual(fast.CurrentBoard(), slow.CurrentBoard()) {
+				t.Fatalf("%s: FastForward(%d) differs from Tick at generation %d", rs, k, slow.Turn)
+			}
+		}
+	}
+}
+
+func TestCloseKeepsGamePlayable(t *testing.T) {
+	soup := randomSoup(21, 16)
+	slow := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	fast := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, History: game.NewHistory(100)}
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 8; i++ {
+			slow.Tick()
+		}
+		if err := fast.FastForward(3); err != nil {
+			t.Fatalf("FastForward(3) failed: %v", err)
+		}
+		// Closing drops the HashLife cache, which the next FastForward starts over
+		fast.Close()
+	}
+	if fast.History != nil {
+		t.Errorf("History should be dropped by Close")
+	}
+	if fast.Turn != slow.Turn || !gridsEqual(fast.CurrentBoard(), slow.CurrentBoard()) {
+		t.Errorf("closed game at generation %d differs from Tick at generation %d", fast.Turn, slow.Turn)
+	}
+}
+func TestFastForwardGliderFarAway(t *testing.T) {
+	glider := parsePattern([]string{".O.", "..O", "OOO"}, 0, 0)
+	g := game.Game{BoardA: glider.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	// A glider moves one cell down and right every 4 generations
+	if err := g.FastForward(40); err != nil {
+		t.Fatalf("FastForward(40) failed: %v", err)
+	}
+	d := 1 << 38
+	want := transform(glider, func(row, col int) (int, int) { return row + d, col + d })
+	if !gridsEqual(g.CurrentBoard(), want) {
+		t.Errorf("glider should have moved %d cells diagonally, got %v", d, g.CurrentBoard().AliveCellsWithinBounds(-d, -d, 2*d, 2*d))
+	}
+}
+
+func TestFastForwardSamplePattern(t *testing.T) {
+	f, err := os.Open("../assets/sample-patterns/10-cell-infinite-growth.rle")
+	if err != nil {
+		t.Fatalf("failed to open sample pattern: %v", err)
+	}
+	defer f.Close()
+	start, _, err := util.ImportRLE(f)
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+
+	slow := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	fast := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	for i := 0; i < 256; i++ {
+		slow.Tick()
+	}
+	if err := fast.FastForward(8); err != nil {
+		t.Fatalf("FastForward(8) failed: %v", err)
+	}
+	if !gridsEqual(fast.CurrentBoard(), slow.CurrentBoard()) {
+		t.Errorf("FastForward(8) differs from 256 ticks")
+	}
+}
+
+func TestHashLifeGarbageCollection(t *testing.T) {
+	soup := randomSoup(5, 24)
+	bounded := hashlife.New(rule.Conway)
+	bounded.MaxNodes = 500
+	unbounded := hashlife.New(rule.Conway)
+	bounded.Load(soup)
+	unbounded.Load(soup)
+
+	slow := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	for step := 0; step < 8; step++ {
+		bounded.Step(3)
+		unbounded.Step(3)
+		for i := 0; i < 8; i++ {
+			slow.Tick()
+		}
+		got := board.NewInfiniteGrid()
+		bounded.Store(got)
+		if !gridsEqual(got, slow.CurrentBoard()) {
+			t.Fatalf("collected universe differs from Tick at generation %d", slow.Turn)
+		}
+	}
+	if bounded.Nodes() >= unbounded.Nodes() {
+		t.Errorf("garbage collection should shrink the cache: %d nodes vs %d without it", bounded.Nodes(), unbounded.Nodes())
+	}
+	if bounded.Population() != slow.CurrentBoard().Len() {
+		t.Errorf("Population() = %d, want %d", bounded.Population(), slow.CurrentBoard().Len())
+	}
+}
+
+func TestFastForwardUnsupportedRule(t *testing.T) {
+	for _, rs := range []string{"B2/S/C3", "R2,C0,M0,S4..6,B5..6,NM", "B2/S34L", "B3/S23:T20,20"} {
+		g := game.Game{BoardA: randomSoup(1, 4), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+		if err := g.FastForward(2); err == nil {
+			t.Errorf("%s: FastForward should fail", rs)
+		}
+		if g.Turn != 1 {
+			t.Errorf("%s: a failed FastForward should not advance the game", rs)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestSeekMatchesTick(t *testing.T) {
+	for _, rs := range []string{"B3/S23", "B2/S/C3"} {
+		r := rule.MustParse(rs)
+		soup := randomSoup(8, 20)
+		g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r, History: game.NewHistory(1000)}
+		for i := 0; i < 150; i++ {
+			g.Tick()
+		}
+		if h := g.History; h.Oldest() != 1 || h.Newest() != 151 || h.Len() != 151 {
+			t.Fatalf("%s: history holds %d generations from %d to %d, want 151 from 1 to 151", rs, h.Len(), h.Oldest(), h.Newest())
+		}
+		// Keyframes, generations between them, and back and forth
+		for _, gen := range []int{1, 65, 100, 151, 2, 129, 40} {
+			if err := g.Seek(gen); err != nil {
+				t.Fatalf("%s: Seek(%d) failed: %v", rs, gen, err)
+			}
+			if g.Turn != gen || !gridsEqual(g.CurrentBoard(), runCpu(soup, r, gen-1)) {
+				t.Errorf("%s: Seek(%d) differs from %d ticks", rs, gen, gen-1)
+			}
+		}
+		if err := g.Rewind(10); err != nil || g.Turn != 30 {
+			t.Errorf("%s: Rewind(10) from generation 40 returned %v at generation %d, want 30", rs, err, g.Turn)
+		}
+
+		// Ticking again replays the recorded generations
+		for i := 0; i < 5; i++ {
+			g.Tick()
+		}
+		if g.History.Newest() != 151 || !gridsEqual(g.CurrentBoard(), runCpu(soup, r, 34)) {
+			t.Errorf("%s: ticking after Rewind should follow the recorded generations", rs)
+		}
+		if err := g.Seek(152); err == nil {
+			t.Errorf("%s: Seek past the last recorded generation should fail", rs)
+		}
+	}
+}
+
+func TestSeekAfterAdvance(t *testing.T) {
+	// HashLife jumps over generations, which are computed again on Seek
+	soup := randomSoup(3, 20)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, History: game.NewHistory(1000)}
+	if err := g.Advance(context.Background(), 300, nil); err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	for _, gen := range []int{301, 200, 1, 257} {
+		if err := g.Seek(gen); err != nil {
+			t.Fatalf("Seek(%d) failed: %v", gen, err)
+		}
+		if g.Turn != gen || !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, gen-1)) {
+			t.Errorf("Seek(%d) after Advance differs from %d ticks", gen, gen-1)
+		}
+	}
+}
+
+func TestHistoryAfterEdit(t *testing.T) {
+	soup := randomSoup(5, 16)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, History: game.NewHistory(1000)}
+	for i := 0; i < 20; i++ {
+		g.Tick()
+	}
+	g.Seek(10)
+	g.SetCell(100, 100, board.Alive)
+	g.SetCell(100, 101, board.Alive)
+	g.SetCell(100, 102, board.Alive)
+	edited := g.CurrentBoard().Clone()
+	g.Tick()
+
+	// The edit replaces the generations after it
+	if g.History.Newest() != 11 {
+		t.Errorf("after an edit at generation 10 and a tick, the history ends at %d, want 11", g.History.Newest())
+	}
+	g.Seek(10)
+	if !gridsEqual(g.CurrentBoard(), edited) {
+		t.Errorf("Seek should return to the edited generation")
+	}
+	g.Seek(5)
+	if !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 4)) {
+		t.Errorf("generations before the edit should be kept")
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	g := game.Game{BoardA: randomSoup(4, 16), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, History: game.NewHistory(100)}
+	for i := 0; i < 500; i++ {
+		g.Tick()
+	}
+	h := g.History
+	if h.Len() < 100 || h.Len() > 100+64 || h.Newest() != 501 || h.Oldest() != 502-h.Len() {
+		t.Errorf("a history of 100 generations holds %d, from %d to %d", h.Len(), h.Oldest(), h.Newest())
+	}
+	if err := g.Seek(h.Oldest() - 1); err == nil {
+		t.Errorf("Seek before the oldest recorded generation should fail")
+	}
+}
+
+func TestReplayBeforeEdit(t *testing.T) {
+	soup := randomSoup(6, 16)
+	g := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, History: game.NewHistory(1000)}
+	for i := 0; i < 9; i++ {
+		g.Tick()
+	}
+	g.SetCell(100, 100, board.Alive)
+	for i := 0; i < 10; i++ {
+		g.Tick()
+	}
+
+	// Ticking from before the edit computes generations without it
+	g.Seek(5)
+	for i := 0; i < 10; i++ {
+		g.Tick()
+	}
+	if !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 14)) {
+		t.Errorf("ticking from before an edit should leave the edit out")
+	}
+	g.Seek(12)
+	if !gridsEqual(g.CurrentBoard(), runCpu(soup, rule.Conway, 11)) {
+		t.Errorf("the generations recorded after the edit should be replaced")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+func TestLatticeRuleParse(t *testing.T) {
+	cases := map[string]string{
+		"B2/S34H":    "B2/S34H",
+		"b2/s34h":    "B2/S34H",
+		"B2/S34/C3H": "B2/S34/C3H",
+		"B4/S3456L":  "B4/S3456L",
+		"B2/S1LE":    "B2/S1LE",
+		"B3/S2ABL":   "B3/S2abL",
+		"B45/S9LV":   "B45/S9LV",
+	}
+	for in, want := range cases {
+		r, err := rule.Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", in, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+	for _, in := range []string{"B7/S34H", "B2-a/S12H", "B4/S2LE", "B2/SaLV", "B0/S2L", "B3/SdL"} {
+		if _, err := rule.Parse(in); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+// singleCellBirths returns the cells born around a single live cell under a B1 rule,
+// which are exactly the cell's neighbors.
+func singleCellBirths(t *testing.T, rs string, row, col int) map[[2]int]bool {
+	t.Helper()
+	start := board.NewInfiniteGrid()
+	start.Set(row, col, board.Alive)
+	g := game.Game{BoardA: start, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+	g.Tick()
+	born := map[[2]int]bool{}
+	for pos := range g.CurrentBoard().All() {
+		born[[2]int{pos[0] - row, pos[1] - col}] = true
+	}
+	return born
+}
+
+func TestHexagonalNeighbors(t *testing.T) {
+	born := singleCellBirths(t, "B1/SH", 0, 0)
+	want := [][2]int{{-1, -1}, {-1, 0}, {0, -1}, {0, 1}, {1, 0}, {1, 1}}
+	if len(born) != len(want) {
+		t.Fatalf("hexagonal cell should have %d neighbors, got %d", len(want), len(born))
+	}
+	for _, d := range want {
+		if !born[d] {
+			t.Errorf("hexagonal neighbor %v missing", d)
+		}
+	}
+}
+
+func TestTriangularNeighbors(t *testing.T) {
+	cases := []struct {
+		rule     string
+		row, col int
+		want     int
+	}{
+		{"B1/SL", 0, 0, 12},
+		{"B1/SL", 0, 1, 12},
+		{"B1/SLE", 0, 0, 3},
+		{"B1/SLV", 3, 4, 9},
+	}
+	for _, tc := range cases {
+		born := singleCellBirths(t, tc.rule, tc.row, tc.col)
+		if len(born) != tc.want {
+			t.Errorf("%s: triangle (%d,%d) should have %d neighbors, got %d", tc.rule, tc.row, tc.col, tc.want, len(born))
+		}
+	}
+
+	// An up triangle shares its base with the triangle below, a down triangle with the one above
+	if born := singleCellBirths(t, "B1/SLE", 0, 0); !born[[2]int{1, 0}] || born[[2]int{-1, 0}] {
+		t.Errorf("up triangle should neighbor the triangle below it, got %v", born)
+	}
+	if born := singleCellBirths(t, "B1/SLE", 0, 1); !born[[2]int{-1, 0}] || born[[2]int{1, 0}] {
+		t.Errorf("down triangle should neighbor the triangle above it, got %v", born)
+	}
+}
+
+func TestTriangularNeighborsAreSymmetric(t *testing.T) {
+	for _, n := range []rule.Neighborhood{rule.Triangular, rule.TriangularEdges, rule.TriangularVertices} {
+		for row := -2; row <= 2; row++ {
+			for col := -2; col <= 2; col++ {
+				for _, d := range rule.TriangleNeighbors(n, row, col) {
+					nr, nc := row+d[0], col+d[1]
+					found := false
+					for _, back := range rule.TriangleNeighbors(n, nr, nc) {
+						if nr+back[0] == row && nc+back[1] == col {
+							found = true
+						}
+					}
+					if !found {
+						t.Errorf("neighborhood %d: (%d,%d) neighbors (%d,%d) but not the reverse", n, row, col, nr, nc)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRLE_HexagonalRule(t *testing.T) {
+	g := makeInfiniteGrid([][]bool{{true, true}})
+	var buf bytes.Buffer
+	if err := util.ExportRLE(&buf, g, rule.MustParse("B2/S34H")); err != nil {
+		t.Fatalf("ExportRLE failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "rule = B2/S34H") {
+		t.Errorf("ExportRLE header missing hexagonal rule: %q", buf.String())
+	}
+	_, r, err := util.ImportRLE(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	if r == nil || r.Neighborhood != rule.Hexagonal {
+		t.Errorf("ImportRLE rule = %v, want B2/S34H", r)
+	}
+}
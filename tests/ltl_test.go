@@ -0,0 +1,184 @@
+package main
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+func randomSoup(seed uint64, size int) *board.InfiniteGrid {
+	rng := rand.New(rand.NewPCG(seed, seed+1))
+	g := board.NewInfiniteGrid()
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			if rng.IntN(2) == 0 {
+				g.Set(r, c, board.Alive)
+			}
+		}
+	}
+	return g
+}
+
+func gridsEqual(a, b board.Board) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	for pos, state := range a.All() {
+		if b.At(pos[0], pos[1]) != state {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLtLRuleParse(t *testing.T) {
+	cases := map[string]string{
+		"R5,C0,M1,S34..58,B34..45,NM": "R5,C0,M1,S34..58,B34..45,NM",
+		"r1,c0,m0,s2..3,b3..3,nm":     "R1,C0,M0,S2..3,B3..3,NM",
+		"R2,C3,M0,S2..4,B3,NN":        "R2,C3,M0,S2..4,B3..3,NN",
+	}
+	for in, want := range cases {
+		r, err := rule.Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", in, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+	for _, in := range []string{
+		"R0,C0,M0,S2..3,B3..3,NM",
+		"R5,C0,M2,S34..58,B34..45,NM",
+		"R5,C0,M1,S34..58,B0..45,NM",
+		"R5,C0,M1,S58..34,B34..45,NM",
+		"R5,C0,M1,S34..58,B34..45,NX",
+		"R5,C0,M1,S34..58,B34..45",
+	} {
+		if _, err := rule.Parse(in); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+func TestLtLRangeOneMatchesConway(t *testing.T) {
+	for _, rs := range []string{"R1,C0,M0,S2..3,B3..3,NM", "R1,C0,M1,S3..4,B3..3,NM"} {
+		soup := randomSoup(7, 24)
+		conway := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+		ltl := game.Game{BoardA: soup.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+		for i := 0; i < 30; i++ {
+			conway.Tick()
+			ltl.Tick()
+			if !gridsEqual(conway.CurrentBoard(), ltl.CurrentBoard()) {
+				t.Fatalf("%s differs from B3/S23 at generation %d", rs, i+1)
+			}
+		}
+	}
+}
+
+// naiveLtL evaluates one generation of a Larger than Life rule by visiting every neighbor.
+func naiveLtL(src board.Board, r *rule.Rule) *board.InfiniteGrid {
+	dst := board.NewInfiniteGrid()
+	radius := r.LtL.Range
+	minRow, minCol, maxRow, maxCol := src.Bounds()
+	for row := minRow - radius; row <= maxRow+radius; row++ {
+		for col := minCol - radius; col <= maxCol+radius; col++ {
+			count := 0
+			for dr := -radius; dr <= radius; dr++ {
+				for dc := -radius; dc <= radius; dc++ {
+					if r.Neighborhood == rule.VonNeumann && abs(dr)+abs(dc) > radius {
+						continue
+					}
+					if (dr != 0 || dc != 0 || r.LtL.Middle) && src.At(row+dr, col+dc).IsAlive() {
+						count++
+					}
+				}
+			}
+			dst.Set(row, col, r.Next(src.At(row, col), count))
+		}
+	}
+	return dst
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestLtLMatchesNaiveCount(t *testing.T) {
+	for _, rs := range []string{
+		"R2,C0,M0,S4..7,B5..6,NN",
+		"R3,C0,M1,S8..14,B9..12,NM",
+		"R2,C4,M1,S3..6,B4..5,NN",
+	} {
+		r := rule.MustParse(rs)
+		g := game.Game{BoardA: randomSoup(3, 16), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+		for i := 0; i < 8; i++ {
+			want := naiveLtL(g.CurrentBoard(), r)
+			g.Tick()
+			if !gridsEqual(want, g.CurrentBoard()) {
+				t.Fatalf("%s: summed-area tick differs from naive count at generation %d", rs, i+1)
+			}
+		}
+	}
+}
+
+func TestLtLSparsePattern(t *testing.T) {
+	r := rule.MustParse("R2,C0,M0,S4..7,B5..6,NN")
+	// Far enough apart for a table per tile, near enough to count naively
+	src := randomSoup(5, 16)
+	for pos, state := range randomSoup(6, 16).All() {
+		src.Set(pos[0]+600, pos[1]+600, state)
+	}
+	g := game.Game{BoardA: src, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	for i := 0; i < 4; i++ {
+		want := naiveLtL(g.CurrentBoard(), r)
+		g.Tick()
+		if !gridsEqual(want, g.CurrentBoard()) {
+			t.Fatalf("tiled tick differs from naive count at generation %d", i+1)
+		}
+	}
+}
+
+func TestLtLFarApartCells(t *testing.T) {
+	r := rule.MustParse("R3,C0,M1,S8..14,B9..12,NM")
+	soup := randomSoup(7, 16)
+	d := 1 << 20
+	far := soup.DeepCopy()
+	for pos, state := range soup.All() {
+		far.Set(pos[0]+d, pos[1]-d, state)
+	}
+	alone := game.Game{BoardA: soup, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	g := game.Game{BoardA: far, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	for i := 0; i < 4; i++ {
+		alone.Tick()
+		g.Tick()
+	}
+	if alone.CurrentBoard().Len() == 0 {
+		t.Fatalf("soup died out, pick another seed")
+	}
+	want := alone.CurrentBoard().(*board.InfiniteGrid).DeepCopy()
+	for pos, state := range alone.CurrentBoard().All() {
+		want.Set(pos[0]+d, pos[1]-d, state)
+	}
+	if !gridsEqual(want, g.CurrentBoard()) {
+		t.Errorf("copies of a pattern %d cells apart should evolve like the pattern alone", d)
+	}
+}
+
+func TestLtLRuleFromRLE(t *testing.T) {
+	_, r, err := util.ImportRLE(strings.NewReader("x = 2, y = 1, rule = R5,C0,M1,S34..58,B34..45,NM\n2o!\n"))
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	if r == nil || r.LtL == nil || r.LtL.Range != 5 {
+		t.Errorf("ImportRLE rule = %v, want Bosco's rule", r)
+	}
+}
@@ -24,10 +24,10 @@ func TestBlinkerOscillator(t *testing.T) {
 	initial := boardA.DeepCopy()
 
 	game.Tick()
-	mid := *game.CurrentBoard()
+	mid := game.CurrentBoard().Clone()
 
 	game.Tick()
-	end := *game.CurrentBoard()
+	end := game.CurrentBoard().Clone()
 
 	if gridsEqualRegion(initial, mid, 0, 0, 4, 4) {
 		t.Errorf("Blinker should change after one tick.")
@@ -52,9 +52,9 @@ func TestToadOscillator(t *testing.T) {
 	game := game.Game{BoardA: boardA, BoardB: boardB, UseA: true, Turn: 1}
 	initial := boardA.DeepCopy()
 	game.Tick()
-	mid := *game.CurrentBoard()
+	mid := game.CurrentBoard().Clone()
 	game.Tick()
-	end := *game.CurrentBoard()
+	end := game.CurrentBoard().Clone()
 	if gridsEqualRegion(initial, mid, 0, 0, 5, 5) {
 		t.Errorf("Toad should change after one tick.")
 	}
@@ -76,11 +76,11 @@ func TestGliderSpaceship(t *testing.T) {
 	game := game.Game{BoardA: boardA, BoardB: boardB, UseA: true, Turn: 1}
 	seen := make(map[string]bool)
 	for i := 0; i < 4; i++ {
-		cur := *game.CurrentBoard()
+		cur := game.CurrentBoard().Clone()
 		key := ""
 		for r := 0; r < 5; r++ {
 			for c := 0; c < 5; c++ {
-				if cur.At(r, c) {
+				if cur.At(r, c).IsAlive() {
 					key += "1"
 				} else {
 					key += "0"
@@ -117,11 +117,11 @@ func TestDiehardPattern(t *testing.T) {
 	maxTicks := 200
 	allDead := false
 	for i := 0; i < maxTicks; i++ {
-		cur := *game.CurrentBoard()
+		cur := game.CurrentBoard().Clone()
 		alive := false
 		for r := 0; r < height; r++ {
 			for c := 0; c < width; c++ {
-				if cur.At(r, c) {
+				if cur.At(r, c).IsAlive() {
 					alive = true
 					break
 				}
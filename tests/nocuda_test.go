@@ -0,0 +1,35 @@
+//go:build !cgo || nocuda
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/gpu"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestBuildWithoutCUDA(t *testing.T) {
+	if gpu.HasCUDA() {
+		t.Errorf("HasCUDA() should be false in a build without CUDA support")
+	}
+	src := randomSoup(2, 10)
+	if err := gpu.Tick(src, board.NewInfiniteGrid(), rule.Conway, board.Topology{}); !errors.Is(err, gpu.ErrNoCUDA) {
+		t.Errorf("gpu.Tick returned %v, want ErrNoCUDA", err)
+	}
+
+	// The cuda backend computes every generation on the CPU instead
+	defer func(name string, logf func(string, ...any)) { game.SetBackend(name); game.Logf = logf }(game.BackendName(), game.Logf)
+	game.Logf = func(string, ...any) {}
+	game.SetBackend("cuda")
+	g := game.Game{BoardA: src.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	for i := 0; i < 5; i++ {
+		g.Tick()
+	}
+	if !gridsEqual(g.CurrentBoard(), runCpu(src, rule.Conway, 5)) {
+		t.Errorf("ticks should fall back to the CPU without CUDA support")
+	}
+}
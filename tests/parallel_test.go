@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+func TestTickParallelMatchesTickCpu(t *testing.T) {
+	soup := transform(randomSoup(9, 60), func(row, col int) (int, int) { return row - 30, col - 10 })
+	for _, rs := range []string{"B3/S23", "B2/S/C3", "B3/S2-i34q", "B2/S34H"} {
+		for _, workers := range []int{2, 3, 7, 100} {
+			r := rule.MustParse(rs)
+			want := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+			got := game.Game{BoardA: soup.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+			for i := 0; i < 30; i++ {
+				want.TickCpu(want.BoardA, want.BoardB)
+				got.TickParallel(got.BoardA, got.BoardB, workers)
+				want.BoardA, want.BoardB = want.BoardB, want.BoardA
+				got.BoardA, got.BoardB = got.BoardB, got.BoardA
+				if !gridsEqual(got.BoardA, want.BoardA) {
+					t.Fatalf("%s with %d workers: differs from TickCpu at generation %d", rs, workers, i+2)
+				}
+			}
+		}
+	}
+}
+
+func TestTickUsesWorkers(t *testing.T) {
+	defer func(w int) { game.Workers = w }(game.Workers)
+	game.Workers = 4
+	blinker := makeInfiniteGrid([][]bool{{true, true, true}})
+	g := game.Game{BoardA: blinker.DeepCopy(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	g.Tick()
+	want := makeInfiniteGrid([][]bool{{false, true}, {false, true}, {false, true}})
+	want = transform(want, func(row, col int) (int, int) { return row - 1, col })
+	if !gridsEqual(g.CurrentBoard(), want) {
+		t.Errorf("blinker should flip with several workers")
+	}
+}
+
+func loadSample(b *testing.B, name string) board.Board {
+	f, err := os.Open("../assets/sample-patterns/" + name)
+	if err != nil {
+		b.Fatalf("failed to open sample pattern: %v", err)
+	}
+	defer f.Close()
+	start, _, err := util.ImportRLE(f)
+	if err != nil {
+		b.Fatalf("ImportRLE failed: %v", err)
+	}
+	return start
+}
+
+func benchmarkTick(b *testing.B, start board.Board, workers int) {
+	g := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if workers == 1 {
+			g.TickCpu(g.BoardA, g.BoardB)
+		} else {
+			g.TickParallel(g.BoardA, g.BoardB, workers)
+		}
+		g.BoardA, g.BoardB = g.BoardB, g.BoardA
+	}
+}
+
+func BenchmarkTickSoup(b *testing.B) {
+	soup := randomSoup(1, 256)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) { benchmarkTick(b, soup, workers) })
+	}
+}
+
+func BenchmarkTickTuringMachine(b *testing.B) {
+	start := loadSample(b, "turing-machine.rle")
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) { benchmarkTick(b, start, workers) })
+	}
+}
@@ -2,9 +2,13 @@ package main
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/rule"
 	"github.com/kvitebjorn/gol/internal/util"
 )
 
@@ -15,10 +19,10 @@ func TestRLE_Block(t *testing.T) {
 	}
 	g := makeInfiniteGrid(block)
 	var buf bytes.Buffer
-	if err := util.ExportRLE(&buf, g); err != nil {
+	if err := util.ExportRLE(&buf, g, nil); err != nil {
 		t.Fatalf("ExportRLE failed: %v", err)
 	}
-	g2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	g2, _, err := util.ImportRLE(strings.NewReader(buf.String()))
 	if err != nil {
 		t.Fatalf("ImportRLE failed: %v", err)
 	}
@@ -29,7 +33,7 @@ func TestRLE_Block(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g.At(i, j) {
+				if g.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -41,7 +45,7 @@ func TestRLE_Block(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g2.At(i, j) {
+				if g2.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -58,10 +62,10 @@ func TestRLE_Blinker(t *testing.T) {
 	}
 	g := makeInfiniteGrid(blinker)
 	var buf bytes.Buffer
-	if err := util.ExportRLE(&buf, g); err != nil {
+	if err := util.ExportRLE(&buf, g, nil); err != nil {
 		t.Fatalf("ExportRLE failed: %v", err)
 	}
-	g2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	g2, _, err := util.ImportRLE(strings.NewReader(buf.String()))
 	if err != nil {
 		t.Fatalf("ImportRLE failed: %v", err)
 	}
@@ -72,7 +76,7 @@ func TestRLE_Blinker(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g.At(i, j) {
+				if g.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -84,7 +88,7 @@ func TestRLE_Blinker(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g2.At(i, j) {
+				if g2.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -102,10 +106,10 @@ func TestRLE_Toad(t *testing.T) {
 	}
 	g := makeInfiniteGrid(toad)
 	var buf bytes.Buffer
-	if err := util.ExportRLE(&buf, g); err != nil {
+	if err := util.ExportRLE(&buf, g, nil); err != nil {
 		t.Fatalf("ExportRLE failed: %v", err)
 	}
-	g2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	g2, _, err := util.ImportRLE(strings.NewReader(buf.String()))
 	if err != nil {
 		t.Fatalf("ImportRLE failed: %v", err)
 	}
@@ -116,7 +120,7 @@ func TestRLE_Toad(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g.At(i, j) {
+				if g.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -128,7 +132,7 @@ func TestRLE_Toad(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g2.At(i, j) {
+				if g2.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -150,10 +154,10 @@ func TestRLE_Beacon(t *testing.T) {
 	cols := len(beacon[0])
 	g := makeInfiniteGrid(beacon)
 	var buf bytes.Buffer
-	if err := util.ExportRLE(&buf, g); err != nil {
+	if err := util.ExportRLE(&buf, g, nil); err != nil {
 		t.Fatalf("ExportRLE failed: %v", err)
 	}
-	g2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	g2, _, err := util.ImportRLE(strings.NewReader(buf.String()))
 	if err != nil {
 		t.Fatalf("ImportRLE failed: %v", err)
 	}
@@ -172,10 +176,10 @@ func TestRLE_Glider(t *testing.T) {
 	cols := len(glider[0])
 	g := makeInfiniteGrid(glider)
 	var buf bytes.Buffer
-	if err := util.ExportRLE(&buf, g); err != nil {
+	if err := util.ExportRLE(&buf, g, nil); err != nil {
 		t.Fatalf("ExportRLE failed: %v", err)
 	}
-	g2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	g2, _, err := util.ImportRLE(strings.NewReader(buf.String()))
 	if err != nil {
 		t.Fatalf("ImportRLE failed: %v", err)
 	}
@@ -192,10 +196,10 @@ func TestRLE_Diehard(t *testing.T) {
 	}
 	g := makeInfiniteGrid(diehard)
 	var buf bytes.Buffer
-	if err := util.ExportRLE(&buf, g); err != nil {
+	if err := util.ExportRLE(&buf, g, nil); err != nil {
 		t.Fatalf("ExportRLE failed: %v", err)
 	}
-	g2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	g2, _, err := util.ImportRLE(strings.NewReader(buf.String()))
 	if err != nil {
 		t.Fatalf("ImportRLE failed: %v", err)
 	}
@@ -206,7 +210,7 @@ func TestRLE_Diehard(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g.At(i, j) {
+				if g.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -218,7 +222,7 @@ func TestRLE_Diehard(t *testing.T) {
 		for i := minRow; i <= maxRow; i++ {
 			var row string
 			for j := minCol; j <= maxCol; j++ {
-				if g2.At(i, j) {
+				if g2.At(i, j).IsAlive() {
 					row += "O"
 				} else {
 					row += "."
@@ -228,3 +232,87 @@ func TestRLE_Diehard(t *testing.T) {
 		}
 	}
 }
+
+func TestRLE_RuleRoundTrip(t *testing.T) {
+	g := makeInfiniteGrid([][]bool{{true, true, true}})
+	highLife := rule.MustParse("B36/S23")
+	var buf bytes.Buffer
+	if err := util.ExportRLE(&buf, g, highLife); err != nil {
+		t.Fatalf("ExportRLE failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "x = 3, y = 1, rule = B36/S23\n") {
+		t.Errorf("ExportRLE header missing rule: %q", buf.String())
+	}
+	_, r, err := util.ImportRLE(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	if r == nil || r.String() != "B36/S23" {
+		t.Errorf("ImportRLE rule = %v, want B36/S23", r)
+	}
+}
+
+func TestRLE_NoRule(t *testing.T) {
+	_, r, err := util.ImportRLE(strings.NewReader("x = 2, y = 1\n2o!\n"))
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	if r != nil {
+		t.Errorf("ImportRLE without rule field should return a nil rule, got %v", r)
+	}
+}
+
+func TestRLE_SamplePatternRules(t *testing.T) {
+	files, err := filepath.Glob("../assets/sample-patterns/*.rle")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no sample patterns found: %v", err)
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		_, r, err := util.ImportRLE(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: ImportRLE failed: %v", name, err)
+			continue
+		}
+		if r == nil || r.String() != "B3/S23" {
+			t.Errorf("%s: rule = %v, want B3/S23", name, r)
+		}
+	}
+}
+
+func TestRLE_InvalidRule(t *testing.T) {
+	if _, _, err := util.ImportRLE(strings.NewReader("x = 1, y = 1, rule = B9/S23\no!\n")); err == nil {
+		t.Errorf("ImportRLE should reject an invalid rule")
+	}
+}
+
+func TestRLE_MultiState(t *testing.T) {
+	g := board.NewInfiniteGrid()
+	g.Set(0, 0, board.Alive)
+	g.Set(0, 1, 2)
+	g.Set(0, 2, 2)
+	g.Set(1, 3, 30)
+	r := rule.MustParse("B2/S/C40")
+	var buf bytes.Buffer
+	if err := util.ExportRLE(&buf, g, r); err != nil {
+		t.Fatalf("ExportRLE failed: %v", err)
+	}
+	want := "x = 4, y = 2, rule = B2/S/C40\nA2B.$\n3.pF!\n"
+	if buf.String() != want {
+		t.Errorf("ExportRLE = %q, want %q", buf.String(), want)
+	}
+	g2, r2, err := util.ImportRLE(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ImportRLE failed: %v", err)
+	}
+	if r2 == nil || r2.States != 40 {
+		t.Errorf("ImportRLE rule = %v, want B2/S/C40", r2)
+	}
+	if !gridsEqualRegion(g, g2, 0, 0, 1, 3) {
+		t.Errorf("MultiState: exported/imported grid does not match original")
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestRuleParse(t *testing.T) {
+	cases := map[string]string{
+		"B3/S23":       "B3/S23",
+		"b3/s23":       "B3/S23",
+		"B36/S23":      "B36/S23",
+		"S23/B3":       "B3/S23",
+		"s23/b3":       "B3/S23",
+		"23/3":         "B3/S23",
+		"B2/S":         "B2/S",
+		"B3678/S34678": "B3678/S34678",
+		" B3/S23 ":     "B3/S23",
+		"B2/S/C3":      "B2/S/C3",
+		"/2/3":         "B2/S/C3",
+		"345/2/4":      "B2/S345/C4",
+		"B3/S23/C2":    "B3/S23",
+	}
+	for in, want := range cases {
+		r, err := rule.Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", in, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRuleParseInvalid(t *testing.T) {
+	for _, in := range []string{"", "B3", "B9/S23", "X3/S23", "B3/B23", "B0/S8", "B2/S/C1", "B2/S/C257", "B2/S/Cx"} {
+		if _, err := rule.Parse(in); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+func TestSeedsRule(t *testing.T) {
+	start := [][]bool{
+		{true, true},
+	}
+	boardA := makeInfiniteGrid(start)
+	g := game.Game{BoardA: boardA, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B2/S")}
+	g.Tick()
+	cur := g.CurrentBoard()
+
+	want := [][2]int{{-1, 0}, {-1, 1}, {1, 0}, {1, 1}}
+	if cur.Len() != len(want) {
+		t.Fatalf("Seeds: expected %d live cells, got %d", len(want), cur.Len())
+	}
+	for _, p := range want {
+		if !cur.At(p[0], p[1]).IsAlive() {
+			t.Errorf("Seeds: expected cell (%d,%d) to be born", p[0], p[1])
+		}
+	}
+}
+
+func TestHighLifeBirthOnSix(t *testing.T) {
+	start := [][]bool{
+		{true, true, true},
+		{false, false, false},
+		{true, true, true},
+	}
+	conway := game.Game{BoardA: makeInfiniteGrid(start), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	highLife := game.Game{BoardA: makeInfiniteGrid(start), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B36/S23")}
+	conway.Tick()
+	highLife.Tick()
+
+	if conway.CurrentBoard().At(1, 1).IsAlive() {
+		t.Errorf("B3/S23: center cell with 6 neighbors should stay dead")
+	}
+	if !highLife.CurrentBoard().At(1, 1).IsAlive() {
+		t.Errorf("B36/S23: center cell with 6 neighbors should be born")
+	}
+}
+
+func TestBriansBrainRule(t *testing.T) {
+	start := [][]bool{
+		{true, true},
+	}
+	g := game.Game{BoardA: makeInfiniteGrid(start), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B2/S/C3")}
+
+	g.Tick()
+	cur := g.CurrentBoard()
+	for _, p := range [][2]int{{0, 0}, {0, 1}} {
+		if got := cur.At(p[0], p[1]); got != 2 {
+			t.Errorf("Brian's Brain: cell (%d,%d) should be dying (state 2), got %d", p[0], p[1], got)
+		}
+	}
+	for _, p := range [][2]int{{-1, 0}, {-1, 1}, {1, 0}, {1, 1}} {
+		if !cur.At(p[0], p[1]).IsAlive() {
+			t.Errorf("Brian's Brain: cell (%d,%d) should be born", p[0], p[1])
+		}
+	}
+
+	g.Tick()
+	cur = g.CurrentBoard()
+	for _, p := range [][2]int{{0, 0}, {0, 1}} {
+		if got := cur.At(p[0], p[1]); got != board.Dead {
+			t.Errorf("Brian's Brain: cell (%d,%d) should be dead after its dying state, got %d", p[0], p[1], got)
+		}
+	}
+}
+
+func TestIsotropicRuleParse(t *testing.T) {
+	cases := map[string]string{
+		"B2-a/S12":                         "B2-a/S12",
+		"B3/S2-i34q":                       "B3/S2-i34q",
+		"b2ce/s12":                         "B2ce/S12",
+		"B2-a/S12/C3":                      "B2-a/S12/C3",
+		"B3cekainyqjr/S2cekain3cekainyqjr": "B3/S23",
+		"B2cekain/S":                       "B2/S",
+	}
+	for in, want := range cases {
+		r, err := rule.Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", in, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+	for _, in := range []string{"B2z/S", "B2-/S", "B0c/S", "B3/S1a"} {
+		if _, err := rule.Parse(in); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+func TestIsotropicBirthDependsOnShape(t *testing.T) {
+	// Both patterns give the dead cell at (1,1) two live neighbors: 2a is a corner
+	// and an adjacent edge, 2e is two adjacent edges
+	twoA := [][]bool{
+		{true, true, false},
+		{false, false, false},
+	}
+	twoE := [][]bool{
+		{false, true, false},
+		{true, false, false},
+	}
+	r := rule.MustParse("B2-a/S12")
+	for name, tc := range map[string]struct {
+		start [][]bool
+		born  bool
+	}{"2a": {twoA, false}, "2e": {twoE, true}} {
+		g := game.Game{BoardA: makeInfiniteGrid(tc.start), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+		g.Tick()
+		if got := g.CurrentBoard().At(1, 1).IsAlive(); got != tc.born {
+			t.Errorf("B2-a/S12 with %s neighborhood: born = %v, want %v", name, got, tc.born)
+		}
+	}
+}
+
+func TestTLifeSurvival(t *testing.T) {
+	// The middle of a line of three has two opposite edge neighbors (2i),
+	// which survives in Conway's Life but not in tlife (B3/S2-i34q)
+	line := [][]bool{
+		{true, true, true},
+	}
+	conway := game.Game{BoardA: makeInfiniteGrid(line), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+	tlife := game.Game{BoardA: makeInfiniteGrid(line), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B3/S2-i34q")}
+	conway.Tick()
+	tlife.Tick()
+	if !conway.CurrentBoard().At(0, 1).IsAlive() {
+		t.Errorf("B3/S23: middle of a line of three should survive")
+	}
+	if tlife.CurrentBoard().At(0, 1).IsAlive() {
+		t.Errorf("B3/S2-i34q: middle of a line of three should die")
+	}
+}
+
+func TestIsotropicRuleIsRotationInvariant(t *testing.T) {
+	const size = 16
+	rng := rand.New(rand.NewPCG(1, 2))
+	soup := board.NewInfiniteGrid()
+	rotated := board.NewInfiniteGrid()
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			if rng.IntN(2) == 0 {
+				soup.Set(r, c, board.Alive)
+				// Rotate a quarter turn clockwise: (r, c) -> (c, -r)
+				rotated.Set(c, -r, board.Alive)
+			}
+		}
+	}
+	tl := rule.MustParse("B3/S2-i34q")
+	a := game.Game{BoardA: soup, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: tl}
+	b := game.Game{BoardA: rotated, BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: tl}
+	for i := 0; i < 20; i++ {
+		a.Tick()
+		b.Tick()
+	}
+	ca, cb := a.CurrentBoard(), b.CurrentBoard()
+	if ca.Len() != cb.Len() {
+		t.Fatalf("rotated soup has %d cells, original has %d", cb.Len(), ca.Len())
+	}
+	for pos := range ca.All() {
+		if !cb.At(pos[1], -pos[0]).IsAlive() {
+			t.Fatalf("cell (%d,%d) has no rotated counterpart", pos[0], pos[1])
+		}
+	}
+}
@@ -5,20 +5,20 @@ import (
 )
 
 // Helper to create an InfiniteGrid from [][]bool, with (0,0) at upper-left
-func makeInfiniteGrid(pattern [][]bool) board.InfiniteGrid {
+func makeInfiniteGrid(pattern [][]bool) *board.InfiniteGrid {
 	grid := board.NewInfiniteGrid()
 	for i := range pattern {
 		for j := range pattern[i] {
 			if pattern[i][j] {
-				grid.Set(i, j, true)
+				grid.Set(i, j, board.Alive)
 			}
 		}
 	}
 	return grid
 }
 
-// Helper to compare two InfiniteGrids in a given region
-func gridsEqualRegion(a, b board.InfiniteGrid, minRow, minCol, maxRow, maxCol int) bool {
+// Helper to compare two boards in a given region
+func gridsEqualRegion(a, b board.Board, minRow, minCol, maxRow, maxCol int) bool {
 	for i := minRow; i <= maxRow; i++ {
 		for j := minCol; j <= maxCol; j++ {
 			if a.At(i, j) != b.At(i, j) {
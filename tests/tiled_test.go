@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+func toTiled(g board.Board) *board.TiledGrid {
+	out := board.NewTiledGrid()
+	for pos, state := range g.All() {
+		out.Set(pos[0], pos[1], state)
+	}
+	return out
+}
+
+// compareTiled runs a pattern on a map board and on a tiled board, and reports the
+// first generation where they differ.
+func compareTiled(t *testing.T, name string, start board.Board, rs string, generations int) {
+	t.Helper()
+	r := rule.MustParse(rs)
+	want := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	got := game.Game{BoardA: toTiled(start), BoardB: board.NewTiledGrid(), UseA: true, Turn: 1, Rule: r}
+	for i := 0; i < generations; i++ {
+		want.TickCpu(want.BoardA, want.BoardB)
+		got.TickCpu(got.BoardA, got.BoardB)
+		want.BoardA, want.BoardB = want.BoardB, want.BoardA
+		got.BoardA, got.BoardB = got.BoardB, got.BoardA
+		if !gridsEqual(got.BoardA, want.BoardA) {
+			t.Fatalf("%s %s: tiled board differs from the map board at generation %d", name, rs, i+2)
+		}
+	}
+}
+
+func TestTiledTickMatchesTickCpu(t *testing.T) {
+	for _, rs := range []string{"B3/S23", "B36/S23", "B2/S34H", "B1/S012345678", "B3678/S34678"} {
+		// The soup straddles tile edges and corners in every direction
+		soup := transform(randomSoup(11, 150), func(row, col int) (int, int) { return row - 75, col - 100 })
+		compareTiled(t, "soup", soup, rs, 40)
+	}
+}
+
+func TestTiledTickSamplePatterns(t *testing.T) {
+	for _, name := range []string{"1beacon.rle", "rats.rle", "10-cell-infinite-growth.rle"} {
+		f, err := os.Open("../assets/sample-patterns/" + name)
+		if err != nil {
+			t.Fatalf("failed to open sample pattern: %v", err)
+		}
+		start, _, err := util.ImportRLE(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: ImportRLE failed: %v", name, err)
+		}
+		compareTiled(t, name, start, "B3/S23", 200)
+	}
+}
+
+// Rules the bit-parallel tick can't run fall back to the generic CPU tick.
+func TestTiledTickFallback(t *testing.T) {
+	soup := randomSoup(3, 40)
+	for _, rs := range []string{"B2/S/C3", "B3/S2-i34q", "B2/S34L", "B3/S23:T30,30", "R2,C0,M0,S4..6,B5..6,NM"} {
+		compareTiled(t, "soup", soup, rs, 20)
+	}
+}
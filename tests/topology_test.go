@@ -0,0 +1,202 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+)
+
+func TestTopologyRuleParse(t *testing.T) {
+	cases := map[string]string{
+		"B3/S23:T100,80":                 "B3/S23:T100,80",
+		"b3/s23:t100,80":                 "B3/S23:T100,80",
+		"B3/S23:P50":                     "B3/S23:P50,50",
+		"B3/S23:T100+5,80":               "B3/S23:T100+5,80",
+		"B3/S23:T100,80-3":               "B3/S23:T100,80-3",
+		"B3/S23:K100*,80":                "B3/S23:K100*,80",
+		"B3/S23:K100,80*":                "B3/S23:K100,80*",
+		"B3/S23:C30,20":                  "B3/S23:C30,20",
+		"B2/S34H:T20,20":                 "B2/S34H:T20,20",
+		"B2/S34L:T20,10":                 "B2/S34L:T20,10",
+		"R2,C0,M0,S2..3,B3..3,NM:T40,40": "R2,C0,M0,S2..3,B3..3,NM:T40,40",
+	}
+	for in, want := range cases {
+		r, err := rule.Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", in, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+
+	for _, in := range []string{
+		"B3/S23:", "B3/S23:X10,10", "B3/S23:T0,10", "B3/S23:T10,", "B3/S23:K10,10",
+		"B3/S23:K10*,10*", "B3/S23:P10+1,10", "B3/S23:T10+1,10+1", "B3/S23:C10*,10",
+		"B2/S34L:K10*,10", "B2/S34L:T11,10", "B2/S34L:T10+1,10",
+	} {
+		if _, err := rule.Parse(in); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+func TestTopologyWrap(t *testing.T) {
+	cases := []struct {
+		topology string
+		in, want [2]int
+	}{
+		// A 10x8 grid spans rows -4..3 and columns -5..4
+		{"T10,8", [2]int{-5, 0}, [2]int{3, 0}},
+		{"T10,8", [2]int{0, 5}, [2]int{0, -5}},
+		{"T10,8", [2]int{4, -6}, [2]int{-4, 4}},
+		{"T10+3,8", [2]int{4, 0}, [2]int{-4, 3}},
+		{"T10+3,8", [2]int{-5, 0}, [2]int{3, -3}},
+		{"T10,8+2", [2]int{0, 5}, [2]int{2, -5}},
+		{"K10*,8", [2]int{4, -5}, [2]int{-4, 4}},
+		{"K10*,8", [2]int{0, 5}, [2]int{0, -5}},
+		{"K10,8*", [2]int{-4, 5}, [2]int{3, -5}},
+		{"C10,8", [2]int{4, -5}, [2]int{-4, 4}},
+		{"C10,8", [2]int{-4, 5}, [2]int{3, -5}},
+		{"P10,8", [2]int{3, 4}, [2]int{3, 4}},
+	}
+	for _, tc := range cases {
+		topo, err := board.ParseTopology(tc.topology)
+		if err != nil {
+			t.Fatalf("ParseTopology(%q) failed: %v", tc.topology, err)
+		}
+		row, col, ok := topo.Wrap(tc.in[0], tc.in[1])
+		if !ok || [2]int{row, col} != tc.want {
+			t.Errorf("%s: Wrap(%v) = (%d, %d, %v), want %v", tc.topology, tc.in, row, col, ok, tc.want)
+		}
+	}
+
+	plane, _ := board.ParseTopology("P10,8")
+	if _, _, ok := plane.Wrap(4, 0); ok {
+		t.Errorf("P10,8: Wrap(4, 0) should be off the grid")
+	}
+}
+
+// parsePattern builds a grid from rows of '.' and 'O', with (row, col) at the upper-left.
+func parsePattern(rows []string, row, col int) *board.InfiniteGrid {
+	g := board.NewInfiniteGrid()
+	for i, line := range rows {
+		for j, ch := range line {
+			if ch == 'O' {
+				g.Set(row+i, col+j, board.Alive)
+			}
+		}
+	}
+	return g
+}
+
+// transform maps every cell of a grid through f.
+func transform(g board.Board, f func(row, col int) (int, int)) *board.InfiniteGrid {
+	out := board.NewInfiniteGrid()
+	for pos, state := range g.All() {
+		row, col := f(pos[0], pos[1])
+		out.Set(row, col, state)
+	}
+	return out
+}
+
+func runBounded(start board.Board, rs string, generations int) board.Board {
+	g := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse(rs)}
+	for i := 0; i < generations; i++ {
+		g.Tick()
+	}
+	return g.CurrentBoard()
+}
+
+// A lightweight spaceship travelling north at c/2, and one travelling west
+var (
+	lwssNorth = []string{".OOO", "O..O", "...O", "...O", "O.O."}
+	lwssWest  = []string{".O..O", "O....", "O...O", "OOOO."}
+)
+
+func TestGliderCrossesTorus(t *testing.T) {
+	glider := parsePattern([]string{".O.", "..O", "OOO"}, -1, -1)
+	// A glider moves one cell diagonally every 4 generations
+	if got := runBounded(glider, "B3/S23:T8,8", 32); !gridsEqual(got, glider) {
+		t.Errorf("glider should return to its start after crossing an 8x8 torus")
+	}
+	ltl := "R1,C0,M0,S2..3,B3..3,NM:T8,8"
+	if got := runBounded(glider, ltl, 32); !gridsEqual(got, glider) {
+		t.Errorf("glider should return to its start after crossing an 8x8 torus under %s", ltl)
+	}
+}
+
+func TestSpaceshipCrossesShiftedTorus(t *testing.T) {
+	start := parsePattern(lwssNorth, -2, -2)
+	// Crossing the top edge of T16-3,20 lands 3 columns to the right of the start
+	want := transform(start, func(row, col int) (int, int) { return row, col + 3 })
+	if got := runBounded(start, "B3/S23:T16-3,20", 40); !gridsEqual(got, want) {
+		t.Errorf("spaceship should come back shifted by 3 columns")
+	}
+}
+
+func TestSpaceshipCrossesReversedEdge(t *testing.T) {
+	// 16x20 grids span columns -8..7 and rows -10..9
+	mirrorCols := func(row, col int) (int, int) { return row, -1 - col }
+	mirrorRows := func(row, col int) (int, int) { return -1 - row, col }
+	identity := func(row, col int) (int, int) { return row, col }
+	cases := []struct {
+		rule        string
+		pattern     []string
+		generations int
+		want        func(row, col int) (int, int)
+	}{
+		{"B3/S23:K16*,20", lwssNorth, 40, mirrorCols},
+		{"B3/S23:K16*,20", lwssWest, 32, identity},
+		{"B3/S23:K16,20*", lwssWest, 32, mirrorRows},
+		{"B3/S23:K16,20*", lwssNorth, 40, identity},
+		{"B3/S23:C16,20", lwssNorth, 40, mirrorCols},
+		{"B3/S23:C16,20", lwssWest, 32, mirrorRows},
+	}
+	for _, tc := range cases {
+		start := parsePattern(tc.pattern, 1, 1)
+		want := transform(start, tc.want)
+		if got := runBounded(start, tc.rule, tc.generations); !gridsEqual(got, want) {
+			t.Errorf("%s: spaceship came back in the wrong place after %d generations", tc.rule, tc.generations)
+		}
+	}
+}
+
+func TestPlaneClipsPattern(t *testing.T) {
+	topo := rule.MustParse("B3/S23:P12,12").Topology
+	g := game.Game{BoardA: randomSoup(3, 16), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: rule.MustParse("B3/S23:P12,12")}
+	for i := 0; i < 50; i++ {
+		g.Tick()
+		for pos := range g.CurrentBoard().All() {
+			if !topo.Contains(pos[0], pos[1]) {
+				t.Fatalf("generation %d: cell %v is outside the plane", i+1, pos)
+			}
+		}
+	}
+}
+
+func TestBoundedMatchesUnboundedAwayFromEdges(t *testing.T) {
+	for _, rs := range []string{"B3/S23", "B3/S2-i34q", "B2/S34H", "B2/S34L", "R2,C0,M0,S4..6,B5..6,NM"} {
+		soup := randomSoup(11, 10)
+		unbounded := runBounded(soup, rs, 3)
+		bounded := runBounded(soup, rs+":T60,60", 3)
+		if !gridsEqual(unbounded, bounded) {
+			t.Errorf("%s: a pattern far from the edges of a torus should evolve as on an unbounded grid", rs)
+		}
+	}
+}
+
+func TestGameTopologyOverridesRule(t *testing.T) {
+	torus, _ := board.ParseTopology("T8,8")
+	g := game.Game{Rule: rule.MustParse("B3/S23:P20,20"), Topology: torus}
+	if got := g.ActiveTopology(); got != torus {
+		t.Errorf("ActiveTopology() = %v, want %v", got, torus)
+	}
+	g.Topology = board.Topology{}
+	if got := g.ActiveTopology(); got.Kind != board.Plane {
+		t.Errorf("ActiveTopology() = %v, want the rule's plane", got)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kvitebjorn/gol/internal/board"
+	"github.com/kvitebjorn/gol/internal/game"
+	"github.com/kvitebjorn/gol/internal/rule"
+	"github.com/kvitebjorn/gol/internal/util"
+)
+
+// compareTracked runs a pattern with TickCpu and with change tracking, calling
+// between(i, g) on the tracked game before generation i, and reports the first
+// generation where they differ.
+func compareTracked(t *testing.T, name string, start board.Board, r *rule.Rule, generations int, between func(i int, g *game.Game)) {
+	t.Helper()
+	defer func(v bool) { game.TrackChanges = v }(game.TrackChanges)
+	want := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	got := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1, Rule: r}
+	for i := 0; i < generations; i++ {
+		if between != nil {
+			between(i, &got)
+			between(i, &want)
+		}
+		game.TrackChanges = false
+		want.Tick()
+		game.TrackChanges = true
+		got.Tick()
+		if !gridsEqual(got.CurrentBoard(), want.CurrentBoard()) {
+			t.Fatalf("%s %s: tracked tick differs from TickCpu at generation %d", name, r, want.Turn)
+		}
+	}
+}
+
+func TestTrackedTickSamplePatterns(t *testing.T) {
+	entries, err := os.ReadDir("../assets/sample-patterns")
+	if err != nil {
+		t.Fatalf("failed to list sample patterns: %v", err)
+	}
+	for _, e := range entries {
+		f, err := os.Open("../assets/sample-patterns/" + e.Name())
+		if err != nil {
+			t.Fatalf("failed to open sample pattern: %v", err)
+		}
+		start, r, err := util.ImportRLE(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: ImportRLE failed: %v", e.Name(), err)
+		}
+		if r == nil {
+			r = rule.Conway
+		}
+		// The big patterns take long enough per generation to keep their runs short
+		generations := 150
+		if start.Len() > 5000 {
+			generations = 15
+		}
+		compareTracked(t, e.Name(), start, r, generations, nil)
+	}
+}
+
+func TestTrackedTickRules(t *testing.T) {
+	soup := transform(randomSoup(4, 90), func(row, col int) (int, int) { return row - 45, col - 45 })
+	for _, rs := range []string{"B3/S23", "B2/S/C3", "B3/S2-i34q", "B2/S34H", "B36/S23"} {
+		compareTracked(t, "soup", soup, rule.MustParse(rs), 80, nil)
+	}
+}
+
+func TestTrackedTickSeesEdits(t *testing.T) {
+	// A block settles at once; cells added to its settled tile must wake it up
+	block := makeInfiniteGrid([][]bool{{true, true}, {true, true}})
+	compareTracked(t, "block", block, rule.Conway, 30, func(i int, g *game.Game) {
+		switch i {
+		case 5:
+			g.SetCell(5, 0, board.Alive)
+			g.SetCell(5, 1, board.Alive)
+			g.SetCell(5, 2, board.Alive)
+		case 12:
+			g.SetCell(0, 0, board.Dead)
+		}
+	})
+}
+
+func TestTrackedTickRuleChange(t *testing.T) {
+	// A block settles at once under Conway, but dies under B3/S2 and the tile it
+	// settled in must be evaluated again; the blinker keeps other tiles changing
+	start := parsePattern([]string{"OO", "OO"}, 0, 0)
+	start.Set(100, 100, board.Alive)
+	start.Set(100, 101, board.Alive)
+	start.Set(100, 102, board.Alive)
+	rules := []*rule.Rule{rule.Conway, rule.MustParse("B3/S2"), rule.MustParse("B36/S23")}
+	compareTracked(t, "block", start, rule.Conway, 30, func(i int, g *game.Game) {
+		if i > 0 && i%10 == 0 {
+			g.Rule = rules[i/10]
+		}
+	})
+}
+
+func TestTrackedTickAfterFastForward(t *testing.T) {
+	soup := randomSoup(8, 30)
+	compareTracked(t, "soup", soup, rule.Conway, 40, func(i int, g *game.Game) {
+		if i%10 == 3 {
+			if err := g.FastForward(3); err != nil {
+				t.Fatalf("FastForward failed: %v", err)
+			}
+		}
+	})
+}
+
+func benchmarkTracked(b *testing.B, start board.Board) {
+	defer func(v bool) { game.TrackChanges = v }(game.TrackChanges)
+	for _, track := range []bool{false, true} {
+		name := "cpu"
+		if track {
+			name = "tracked"
+		}
+		b.Run(name, func(b *testing.B) {
+			game.TrackChanges = track
+			g := game.Game{BoardA: start.Clone(), BoardB: board.NewInfiniteGrid(), UseA: true, Turn: 1}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.Tick()
+			}
+		})
+	}
+}
+
+// A field of blocks with a few oscillators in it only changes around the oscillators
+func BenchmarkTickTrackedStillLifeField(b *testing.B) {
+	field := board.NewInfiniteGrid()
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100; j++ {
+			for _, d := range [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+				field.Set(4*i+d[0], 4*j+d[1], board.Alive)
+			}
+		}
+	}
+	for _, pos := range [][2]int{{-10, 50}, {200, -10}, {410, 300}} {
+		for j := 0; j < 3; j++ {
+			field.Set(pos[0], pos[1]+j, board.Alive)
+		}
+	}
+	benchmarkTracked(b, field)
+}
+
+// Glider streams keep most of the Turing machine changing
+func BenchmarkTickTrackedTuringMachine(b *testing.B) {
+	benchmarkTracked(b, loadSample(b, "turing-machine.rle"))
+}